@@ -5,41 +5,68 @@ import (
 	"github.com/ahstn/karetaker/pkg/actions"
 	"github.com/ahstn/karetaker/pkg/domain"
 	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/pkg/errors"
 	"github.com/thatisuday/commando"
 	"os"
 	"strings"
-	"text/tabwriter"
+	"time"
 )
 
 var allowlist = []string{"default-token", "istio-ca", "sh.helm.release"}
 
 func Age(args map[string]commando.ArgValue, flags map[string]commando.FlagValue) {
-	n, _ := flags["namespace"].GetString()
 	d, _ := flags["dry-run"].GetBool()
 	a, _ := flags["age"].GetString()
 	al, _ := flags["allow"].GetString()
+	idle, _ := flags["idle-for"].GetString()
+	p, _ := flags["prometheus"].GetString()
+	q, _ := flags["idle-query"].GetString()
 	t := args["type"].Value
+	f := outputFormat(flags)
 	allowlist = append(allowlist, strings.Split(al, ",")[:]...)
 
-	config, err := domain.NewAgeConfig(t, a, n, allowlist, d)
+	fmt.Fprintf(os.Stderr, "Using Allow List of: %s\n", allowlist)
+	fmt.Fprintln(os.Stderr, "Connecting to Kubernetes Cluster")
+	o := clientOptions(flags)
+	client, err := kubernetes.DynamicConfig(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	mapper, err := kubernetes.RESTMapper(o)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
 	}
 
-	fmt.Printf("Using Allow List of: %s\n", allowlist)
-	fmt.Println("Connecting to Kubernetes Cluster")
-	client, err := kubernetes.DynamicConfig("")
+	n, err := namespaces(flags, o, client)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
 
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 8, 8, 0, '\t', 0)
-	defer w.Flush()
+	config, err := domain.NewAgeConfig(t, a, n, allowlist, d)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unsupported age: %s\n", errors.Cause(err))
+		os.Exit(1)
+	}
 
-	err = actions.Age(client, config, w)
+	config.IdleFor, err = time.ParseDuration(idle)
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "unsupported idle-for: %s\n", err)
+		os.Exit(1)
+	} else if config.IdleFor > 0 && p == "" {
+		fmt.Fprintln(os.Stderr, "--prometheus is required with --idle-for")
+		os.Exit(1)
 	}
+	config.Prometheus = p
+	config.IdleQuery = q
+
+	if !d {
+		config.Backup = backupDir(flags)
+	}
+
+	results, err := actions.Age(client, mapper, config)
+	render(f, results, err)
 }
@@ -0,0 +1,54 @@
+package actions
+
+import (
+	"fmt"
+	"github.com/ahstn/karetaker/pkg/actions"
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/thatisuday/commando"
+	"os"
+	"strings"
+)
+
+func Broken(args map[string]commando.ArgValue, flags map[string]commando.FlagValue) {
+	d, _ := flags["dry-run"].GetBool()
+	a, _ := flags["age"].GetString()
+	al, _ := flags["allow"].GetString()
+	t := args["type"].Value
+	f := outputFormat(flags)
+	allowlist = append(allowlist, strings.Split(al, ",")[:]...)
+
+	fmt.Fprintf(os.Stderr, "Using Allow List of: %s\n", allowlist)
+	fmt.Fprintln(os.Stderr, "Connecting to Kubernetes Cluster")
+	o := clientOptions(flags)
+	client, err := kubernetes.DynamicConfig(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	mapper, err := kubernetes.RESTMapper(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	n, err := namespaces(flags, o, client)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	config, err := domain.NewBrokenConfig(t, a, n, allowlist, d)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	if !d {
+		config.Backup = backupDir(flags)
+	}
+
+	results, err := actions.Broken(client, mapper, config)
+	render(f, results, err)
+}
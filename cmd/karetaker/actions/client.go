@@ -0,0 +1,81 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/thatisuday/commando"
+	"k8s.io/client-go/dynamic"
+)
+
+// clientOptions reads the kubectl-compatible connection flags shared by every command.
+func clientOptions(flags map[string]commando.FlagValue) kubernetes.ClientOptions {
+	kc, _ := flags["kubeconfig"].GetString()
+	ctx, _ := flags["context"].GetString()
+	cl, _ := flags["cluster"].GetString()
+	as, _ := flags["as"].GetString()
+	ag, _ := flags["as-group"].GetString()
+	rt, _ := flags["request-timeout"].GetString()
+
+	var groups []string
+	if ag != "" {
+		groups = strings.Split(ag, ",")
+	}
+
+	return kubernetes.ClientOptions{
+		Kubeconfig:     kc,
+		Context:        ctx,
+		Cluster:        cl,
+		As:             as,
+		AsGroups:       groups,
+		RequestTimeout: rt,
+	}
+}
+
+// namespaces returns the namespaces to operate in. With '--all-namespaces' or '--namespace-selector'
+// every matching namespace is returned, excluding the system namespaces and '--exclude-namespaces'.
+// Otherwise this is the single default namespace.
+func namespaces(flags map[string]commando.FlagValue, o kubernetes.ClientOptions, c dynamic.Interface) ([]string, error) {
+	all, _ := flags["all-namespaces"].GetBool()
+	sel, _ := flags["namespace-selector"].GetString()
+	ex, _ := flags["exclude-namespaces"].GetString()
+
+	if all || sel != "" {
+		exclude := append([]string{}, kubernetes.SystemNamespaces...)
+		if ex != "" {
+			exclude = append(exclude, strings.Split(ex, ",")...)
+		}
+
+		ns, err := kubernetes.SelectNamespaces(c, sel, exclude)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(os.Stderr, "Using namespaces: %s\n", ns)
+		return ns, nil
+	}
+
+	n, err := defaultNamespace(flags, o)
+	if err != nil {
+		return nil, err
+	}
+	return []string{n}, nil
+}
+
+// defaultNamespace returns the '--namespace' flag, or when not passed, the namespace of the
+// active kubeconfig context (or the pod's namespace when running in-cluster).
+func defaultNamespace(flags map[string]commando.FlagValue, o kubernetes.ClientOptions) (string, error) {
+	if n, _ := flags["namespace"].GetString(); n != "" {
+		return n, nil
+	}
+
+	n, err := kubernetes.DefaultNamespace(o)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(os.Stderr, "Using namespace: %s\n", n)
+	return n, nil
+}
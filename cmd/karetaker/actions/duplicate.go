@@ -2,36 +2,42 @@ package actions
 
 import (
 	"fmt"
+	"github.com/ahstn/karetaker/pkg/actions"
 	"github.com/ahstn/karetaker/pkg/kubernetes"
 	"github.com/ahstn/karetaker/pkg/log"
 	"github.com/thatisuday/commando"
 	"os"
-	"text/tabwriter"
 )
 
 func Duplicate(args map[string]commando.ArgValue, flags map[string]commando.FlagValue) {
-	namespace, _ := flags["namespace"].GetString()
 	filter, _ := flags["filter"].GetString()
+	similarity, _ := flags["similarity"].GetInt()
 	targetLabel := args["target"].Value
+	f := outputFormat(flags)
 
-	s := log.Print("Connecting to Kubernetes Cluster")
-	clientset, err := kubernetes.Config("")
+	s := log.Fprint(os.Stderr, "Connecting to Kubernetes Cluster")
+	o := clientOptions(flags)
+	clientset, err := kubernetes.Config(o)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	client, err := kubernetes.DynamicConfig(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
 	s.Stop()
 
-	s = log.Print(fmt.Sprintf("Fetching Deployments (namespace: %s)", namespace))
-	deployments, err := kubernetes.ListDuplicateDeployments(clientset, namespace, filter, targetLabel)
-	s.Stop()
+	ns, err := namespaces(flags, o, client)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
 
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 8, 8, 0, '\t', 0)
-	defer w.Flush()
+	s = log.Fprint(os.Stderr, fmt.Sprintf("Fetching Deployments (namespaces: %s)", ns))
+	results, err := actions.Duplicate(clientset, ns, filter, targetLabel, float64(similarity)/100)
+	s.Stop()
 
-	fmt.Fprintf(w, "%s\t%s\n", "DEPLOYMENT", "MATCHES")
-	for deployment, matches := range deployments {
-		fmt.Fprintf(w, "%s\t%v\t\n", deployment, matches)
-	}
+	render(f, results, err)
 }
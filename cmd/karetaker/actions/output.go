@@ -0,0 +1,33 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/output"
+	"github.com/thatisuday/commando"
+)
+
+// outputFormat returns the '--output' flag, exiting on unsupported formats before touching the cluster.
+func outputFormat(flags map[string]commando.FlagValue) string {
+	f, _ := flags["output"].GetString()
+	if err := output.Validate(f); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return f
+}
+
+// render writes the results to stdout, followed by the operation's error (if any) to stderr.
+func render(f string, results []domain.Result, err error) {
+	if werr := output.Results(os.Stdout, f, results); werr != nil {
+		fmt.Fprintln(os.Stderr, werr.Error())
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
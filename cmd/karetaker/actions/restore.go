@@ -0,0 +1,43 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ahstn/karetaker/pkg/actions"
+	"github.com/ahstn/karetaker/pkg/backup"
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/thatisuday/commando"
+)
+
+func Restore(args map[string]commando.ArgValue, flags map[string]commando.FlagValue) {
+	from, _ := flags["from"].GetString()
+	n, _ := flags["namespace"].GetString()
+	d, _ := flags["dry-run"].GetBool()
+	f := outputFormat(flags)
+
+	fmt.Fprintln(os.Stderr, "Connecting to Kubernetes Cluster")
+	client, err := kubernetes.DynamicConfig(clientOptions(flags))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	results, err := actions.Restore(client, domain.NewRestoreConfig(from, args["objects"].Value, n, d))
+	render(f, results, err)
+}
+
+// backupDir returns the timestamped directory deleted objects are backed up to, or empty with '--skip-backup'.
+func backupDir(flags map[string]commando.FlagValue) string {
+	root, _ := flags["backup-dir"].GetString()
+	skip, _ := flags["skip-backup"].GetBool()
+	if skip {
+		return ""
+	}
+
+	d := backup.Dir(root, time.Now())
+	fmt.Fprintf(os.Stderr, "Backing up objects to %s before deletion\n", d)
+	return d
+}
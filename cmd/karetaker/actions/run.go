@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ahstn/karetaker/pkg/actions"
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/ahstn/karetaker/pkg/output"
+	"github.com/thatisuday/commando"
+)
+
+func Run(args map[string]commando.ArgValue, flags map[string]commando.FlagValue) {
+	file, _ := flags["file"].GetString()
+	f := outputFormat(flags)
+
+	policy, err := domain.LoadPolicy(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	deletes := false
+	for i, rule := range policy.Rules {
+		policy.Rules[i].Allow = append(append([]string{}, allowlist...), rule.Allow...)
+		deletes = deletes || !rule.IsDryRun()
+	}
+
+	// only dry-run rules never back anything up
+	if deletes {
+		policy.Backup = backupDir(flags)
+	}
+
+	fmt.Fprintf(os.Stderr, "Loaded %d rule(s) from %s\n", len(policy.Rules), file)
+	fmt.Fprintln(os.Stderr, "Connecting to Kubernetes Cluster")
+	o := clientOptions(flags)
+	client, err := kubernetes.DynamicConfig(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	mapper, err := kubernetes.RESTMapper(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	n, err := defaultNamespace(flags, o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	results, err := actions.Run(client, mapper, policy, n)
+	if werr := output.Rules(os.Stdout, f, results); werr != nil {
+		fmt.Fprintln(os.Stderr, werr.Error())
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
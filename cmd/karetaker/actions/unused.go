@@ -6,36 +6,63 @@ import (
 	"github.com/ahstn/karetaker/pkg/domain"
 	"os"
 	"strings"
-	"text/tabwriter"
+	"time"
 
 	"github.com/ahstn/karetaker/pkg/kubernetes"
 	"github.com/thatisuday/commando"
 )
 
 func Unused(args map[string]commando.ArgValue, flags map[string]commando.FlagValue) {
-	n, _ := flags["namespace"].GetString()
 	d, _ := flags["dry-run"].GetBool()
 	a, _ := flags["age"].GetString()
 	al, _ := flags["allow"].GetString()
+	k, _ := flags["keep-revisions"].GetInt()
+	ks, _ := flags["keep-successful"].GetInt()
+	kf, _ := flags["keep-failed"].GetInt()
+	sw, _ := flags["success-window"].GetString()
 	t := args["type"].Value
+	f := outputFormat(flags)
+	if k < 0 {
+		fmt.Fprintf(os.Stderr, "unsupported keep-revisions: %d can't be negative\n", k)
+		os.Exit(1)
+	}
 	allowlist = append(allowlist, strings.Split(al, ",")[:]...)
-	
-	config, err := domain.NewUnusedConfigWithAge(t, a, n, allowlist, d)
 
-	fmt.Printf("Using Allow List of: %s\n", allowlist)
-	fmt.Println("Connecting to Kubernetes Cluster")
-	client, err := kubernetes.DynamicConfig("")
+	fmt.Fprintf(os.Stderr, "Using Allow List of: %s\n", allowlist)
+	fmt.Fprintln(os.Stderr, "Connecting to Kubernetes Cluster")
+	o := clientOptions(flags)
+	client, err := kubernetes.DynamicConfig(o)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
 
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 8, 8, 0, '\t', 0)
-	defer w.Flush()
+	mapper, err := kubernetes.RESTMapper(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	n, err := namespaces(flags, o, client)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
 
-	err = actions.Unused(client, config, w)
+	config, err := domain.NewUnusedConfigWithAge(t, a, n, allowlist, d)
+	config.KeepRevisions = k
+	config.KeepSuccessful = ks
+	config.KeepFailed = kf
+	config.SuccessWindow, err = time.ParseDuration(sw)
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "unsupported success-window: %s\n", err)
+		return
 	}
-}
\ No newline at end of file
+
+	if !d {
+		config.Backup = backupDir(flags)
+	}
+
+	results, err := actions.Unused(client, mapper, config)
+	render(f, results, err)
+}
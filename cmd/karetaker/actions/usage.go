@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"fmt"
+	"github.com/ahstn/karetaker/pkg/actions"
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/thatisuday/commando"
+	"os"
+	"strings"
+)
+
+func Usage(args map[string]commando.ArgValue, flags map[string]commando.FlagValue) {
+	l, _ := flags["limit-threshold"].GetInt()
+	r, _ := flags["request-threshold"].GetInt()
+	al, _ := flags["allow"].GetString()
+	t := args["type"].Value
+	f := outputFormat(flags)
+	allowlist = append(allowlist, strings.Split(al, ",")[:]...)
+
+	fmt.Fprintln(os.Stderr, "Connecting to Kubernetes Cluster")
+	o := clientOptions(flags)
+	client, err := kubernetes.DynamicConfig(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	mapper, err := kubernetes.RESTMapper(o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	n, err := namespaces(flags, o, client)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	config := domain.NewUsageConfig(t, n, allowlist, float64(l), float64(r))
+	results, err := actions.Usage(client, mapper, config)
+	render(f, results, err)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"strings"
+
 	"github.com/ahstn/karetaker/cmd/karetaker/actions"
 	"github.com/thatisuday/commando"
 )
@@ -12,33 +14,117 @@ func main() {
 		SetExecutableName("karetaker").
 		SetVersion("1.0.0")
 
-	commando.
+	duplicate := commando.
 		Register("duplicate").
 		SetDescription("Find similar or duplicate Kubernetes' deployments").
 		AddArgument("target", "label to target similarities and duplicates", "kubernetes.io/instance").
 		AddFlag("filter,f", "deployments label filter (i.e. app=auth)", commando.String, nil).
-		AddFlag("namespace,n", "kubernetes namespace", commando.String, "default").
+		AddFlag("similarity,s", "minimum similarity score (percentage) from labels, images, env vars and requests", commando.Int, 85).
 		SetAction(actions.Duplicate)
 
-	commando.
+	age := commando.
 		Register("age").
 		SetDescription("Find resources older than a certain age").
 		AddArgument("type", "type of resource", "deployment").
 		AddFlag("age,a", "age boundary to filter on", commando.String, "48h").
-		AddFlag("namespace,n", "kubernetes namespace", commando.String, "default").
 		AddFlag("dry-run,d", "if true, only show the resources", commando.Bool, true).
 		AddFlag("allow,A", "allow list (CSV) of name patterns to ignore (i.e. 'istio')", commando.String, "").
+		AddFlag("idle-for", "only select deployments without activity in prometheus over this window, zero disables", commando.String, "0s").
 		SetAction(actions.Age)
 
-	commando.
+	unused := commando.
 		Register("unused").
 		SetDescription("Find resources not in use by another object").
 		AddArgument("type", "type of resource", "configmap").
 		AddFlag("age,a", "age boundary to filter on for certain resources", commando.String, "24h").
-		AddFlag("namespace,n", "kubernetes namespace", commando.String, "default").
 		AddFlag("dry-run,d", "if true, only show the resources", commando.Bool, true).
 		AddFlag("allow,A", "allow list (CSV) of name patterns to ignore (i.e. 'istio')", commando.String, "").
+		AddFlag("keep-revisions", "number of old replicaset revisions to keep per deployment", commando.Int, 0).
+		AddFlag("keep-successful", "number of completed jobs to keep per cronjob, negative uses the cronjob's history limit", commando.Int, -1).
+		AddFlag("keep-failed", "number of failed jobs to keep per cronjob, negative uses the cronjob's history limit", commando.Int, -1).
+		AddFlag("success-window", "how long a cronjob can go without a successful job before it's stale, zero disables", commando.String, "168h").
 		SetAction(actions.Unused)
 
+	broken := commando.
+		Register("broken").
+		SetDescription("Find deployments and statefulsets scaled to zero, unavailable or failing").
+		AddArgument("type", "type of resource", "deployment,statefulset").
+		AddFlag("age,a", "how long a workload must be scaled to zero or unavailable for", commando.String, "24h").
+		AddFlag("dry-run,d", "if true, only show the resources", commando.Bool, true).
+		AddFlag("allow,A", "allow list (CSV) of name patterns to ignore (i.e. 'istio')", commando.String, "").
+		SetAction(actions.Broken)
+
+	usage := commando.
+		Register("usage").
+		SetDescription("Find deployments and statefulsets near their resource limits or far below their requests").
+		AddArgument("type", "type of resource", "deployment,statefulset").
+		AddFlag("limit-threshold", "percentage of cpu or memory limits to report workloads above", commando.Int, 90).
+		AddFlag("request-threshold", "percentage of cpu or memory requests to report workloads below, zero disables", commando.Int, 10).
+		AddFlag("allow,A", "allow list (CSV) of name patterns to ignore (i.e. 'istio')", commando.String, "").
+		SetAction(actions.Usage)
+
+	run := commando.
+		Register("run").
+		SetDescription("Execute the clean-up rules declared in a policy file").
+		AddFlag("file,f", "path to the policy file (YAML)", commando.String, nil).
+		SetAction(actions.Run)
+
+	restore := commando.
+		Register("restore").
+		SetDescription("Re-create objects from a backup taken before deletion").
+		AddArgument("objects...", "objects to restore as type or type/name (i.e. configmap/properties), all by default", "").
+		AddFlag("from,f", "path to the timestamped backup directory", commando.String, nil).
+		AddFlag("dry-run,d", "if true, only show the objects", commando.Bool, true).
+		SetAction(actions.Restore)
+
+	for _, c := range []*commando.Command{duplicate, age, unused, broken, usage} {
+		addNamespaceFlags(c)
+		addClientFlags(c)
+	}
+
+	for _, c := range []*commando.Command{age, unused, broken, run} {
+		c.AddFlag("backup-dir", "directory objects are backed up to before deletion", commando.String, "karetaker-backups")
+		c.AddFlag("skip-backup", "if true, objects aren't backed up before deletion", commando.Bool, nil)
+	}
+
+	for _, c := range []*commando.Command{duplicate, age, unused, broken, usage, run, restore} {
+		c.AddFlag("output,o", "output format (table, json, yaml, csv)", commando.String, "table")
+	}
+
+	addOptionalFlag(age, "prometheus", "address of the prometheus-compatible API used by idle-for (i.e. http://prometheus:9090)")
+	addOptionalFlag(age, "idle-query", "template of the activity query used by idle-for, with {{ .Namespace }}, {{ .Name }} and {{ .Window }} (default: istio_requests_total)")
+
+	addOptionalFlag(run, "namespace,n", "kubernetes namespace for rules without namespaces (default: kubeconfig context's namespace)")
+	addClientFlags(run)
+
+	addOptionalFlag(restore, "namespace,n", "only restore objects from this namespace (default: all namespaces)")
+	addClientFlags(restore)
+
 	commando.Parse(nil)
 }
+
+// addNamespaceFlags registers the flags selecting which namespaces a command operates in.
+// NB: '-A' is already used by '--allow', so '--all-namespaces' has no shorthand.
+func addNamespaceFlags(c *commando.Command) {
+	addOptionalFlag(c, "namespace,n", "kubernetes namespace (default: kubeconfig context's namespace)")
+	c.AddFlag("all-namespaces", "operate across all namespaces (excluding kube-system, kube-public, kube-node-lease)", commando.Bool, nil)
+	addOptionalFlag(c, "namespace-selector", "operate across namespaces matching a label selector (i.e. env=dev)")
+	addOptionalFlag(c, "exclude-namespaces", "namespaces (CSV) to exclude when using all-namespaces or namespace-selector")
+}
+
+// addClientFlags registers the kubectl-compatible connection flags on a command.
+func addClientFlags(c *commando.Command) {
+	addOptionalFlag(c, "kubeconfig", "path to the kubeconfig file to use")
+	addOptionalFlag(c, "context", "name of the kubeconfig context to use")
+	addOptionalFlag(c, "cluster", "name of the kubeconfig cluster to use")
+	addOptionalFlag(c, "as", "username to impersonate for the operation")
+	addOptionalFlag(c, "as-group", "groups (CSV) to impersonate for the operation")
+	c.AddFlag("request-timeout", "time to wait for a single server request, zero means no timeout", commando.String, "0")
+}
+
+// addOptionalFlag registers a string flag without a default value.
+// commando treats these as required, so the requirement is removed after registration.
+func addOptionalFlag(c *commando.Command, names, desc string) {
+	c.AddFlag(names, desc, commando.String, nil)
+	c.Flags[strings.Split(names, ",")[0]].IsRequired = false
+}
@@ -16,4 +16,5 @@ require (
 	k8s.io/apimachinery v0.18.19
 	k8s.io/client-go v0.18.19
 	k8s.io/utils v0.0.0-20200327001022-6496210b90e8 // indirect
+	sigs.k8s.io/yaml v1.2.0
 )
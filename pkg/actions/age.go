@@ -1,56 +1,136 @@
 package actions
 
 import (
+	"context"
 	"fmt"
+	"github.com/ahstn/karetaker/pkg/backup"
 	"github.com/ahstn/karetaker/pkg/domain"
 	"github.com/ahstn/karetaker/pkg/kubernetes"
-	"io"
+	"github.com/ahstn/karetaker/pkg/prometheus"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
-	"time"
 )
 
-// Age for each resource type in 'u.Resources', find objects older than 'u.Age' and delete them.
-func Age(c dynamic.Interface, u domain.Age, o io.Writer) error {
-	for _, resource := range u.Resources {
-		var gvr schema.GroupVersionResource
-
-		switch resource {
-		case "configmap","configmaps":
-			gvr = kubernetes.ConfigMapSchema
-		case "secret","secrets":
-			gvr = kubernetes.SecretSchema
-		case "deploy","deployment","deployments":
-			gvr = kubernetes.DeploymentSchema
-		case "ss","statefulset","statefulsets":
-			gvr = kubernetes.StatefulSetSchema
-		case "svc","service","services":
-			gvr = kubernetes.ServiceSchema
-		case "job","jobs":
-			gvr = kubernetes.JobSchema
-		default:
-			fmt.Fprintf(o, "Unsupported resource: %s, skipping.", resource)
-			continue
-		}
+// Age for each resource type in 'u.Resources', find objects older than 'u.Age' in 'u.Namespaces' and delete them.
+// Resource types are resolved with the RESTMapper 'm', so any namespaced or cluster-scoped type (including custom
+// resources) can be used. Cluster-scoped types are operated on once, regardless of the namespaces.
+// When 'u.IdleFor' is set, only Deployments without activity over that window (queried from 'u.Prometheus') are selected.
+func Age(c dynamic.Interface, m meta.RESTMapper, u domain.Age) ([]domain.Result, error) {
+	mappings, err := ageMappings(m, u.Resources, u.IdleFor > 0)
+	if err != nil {
+		return nil, err
+	}
 
-		list, err := kubernetes.ResourcesOlderThan(c, gvr, u.Namespace, u.Age, u.Allow)
+	var activity prometheus.Client
+	var query prometheus.ActivityQuery
+	if u.IdleFor > 0 {
+		query, err = prometheus.ParseActivityQuery(u.IdleQuery)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		activity = prometheus.NewClient(u.Prometheus)
+	}
+
+	var results []domain.Result
+	for _, mapping := range mappings {
+		gvr := mapping.Resource
+		namespaces := u.Namespaces
+		if !kubernetes.IsNamespaced(mapping) {
+			namespaces = []string{""}
 		}
 
-		fmt.Fprint(o, "RESOURCE\tAGE\tSTATUS\n")
-		for _, item := range list {
-			if u.DryRun {
-				fmt.Fprintf(o, "%s\t%v\tUN-CHANGED (dry-run)\n", item.Name, item.Age.Round(time.Minute))
-			} else {
-				fmt.Fprintf(o, "%s\t%v\tDELETED\n", item.Name, item.Age)
-				err = kubernetes.DeleteResource(c, gvr, u.Namespace, item.Name)
-				if err != nil {
-					fmt.Printf("error deleting %s, continuing...", item.Name)
+		for _, namespace := range namespaces {
+			list, err := kubernetes.ResourcesOlderThan(c, gvr, namespace, u.Age, u.Allow)
+			if err != nil {
+				return results, err
+			}
+
+			for _, item := range list {
+				result := domain.Result{
+					Kind:      gvr.Resource,
+					Namespace: namespace,
+					Name:      item.Name,
+					Age:       item.Age,
+					Expires:   item.Expires,
+					Status:    domain.Expired,
+				}
+
+				if activity != nil {
+					idle, err := prometheus.Idle(activity, query, namespace, item.Name, u.IdleFor)
+					if err != nil {
+						return results, errors.Wrapf(err, "getting activity of %s/%s", namespace, item.Name)
+					} else if !idle {
+						continue
+					}
+					result.Details = "idleFor=" + u.IdleFor.String()
+				}
+
+				if item.Protected != "" {
+					results = append(results, protected(result, item))
+				} else {
+					results = append(results, deleteOrSkip(c, gvr, result, u.DryRun, u.Backup))
 				}
 			}
 		}
 	}
 
-	return nil
+	return results, nil
+}
+
+// ageMappings resolves each of the 'resources' with the RESTMapper 'm', which must be Deployments when 'idle' is set.
+func ageMappings(m meta.RESTMapper, resources []string, idle bool) ([]*meta.RESTMapping, error) {
+	mappings := make([]*meta.RESTMapping, len(resources))
+	for i, resource := range resources {
+		mapping, err := kubernetes.ResolveResource(m, resource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unsupported resource: %s", resource)
+		}
+		if idle && mapping.Resource.GroupResource() != kubernetes.DeploymentSchema.GroupResource() {
+			return nil, errors.Errorf("unsupported resource for idle-for: %s", resource)
+		}
+		mappings[i] = mapping
+	}
+	return mappings, nil
+}
+
+// protected leaves the object of 'r' unchanged, recording why the resource 'item' is protected.
+func protected(r domain.Result, item kubernetes.Resource) domain.Result {
+	r.Status = domain.Protected
+	r.Action = domain.Unchanged
+	r.Reason = item.Protected
+	return r
+}
+
+// deleteOrSkip deletes the object of 'r' (unless 'dryRun') and records the action taken.
+// When 'backupDir' is set, the object is backed up first and isn't deleted if that fails.
+func deleteOrSkip(c dynamic.Interface, gvr schema.GroupVersionResource, r domain.Result, dryRun bool, backupDir string) domain.Result {
+	if dryRun {
+		r.Action = domain.Unchanged
+		r.Reason = domain.ReasonDryRun
+		return r
+	}
+
+	if backupDir != "" {
+		obj, err := c.Resource(gvr).Namespace(r.Namespace).Get(context.TODO(), r.Name, meta_v1.GetOptions{})
+		if err == nil {
+			err = backup.Save(backupDir, gvr, obj)
+		}
+		if err != nil {
+			r.Action = domain.DeleteFailed
+			r.Reason = fmt.Sprintf("backup failed: %s", err)
+			return r
+		}
+	}
+
+	if err := kubernetes.DeleteResource(c, gvr, r.Namespace, r.Name); err != nil {
+		r.Action = domain.DeleteFailed
+		r.Reason = err.Error()
+		return r
+	}
+
+	r.Action = domain.Deleted
+	return r
 }
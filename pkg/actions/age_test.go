@@ -1,19 +1,92 @@
 package actions
 
 import (
-	"bytes"
-	"fmt"
+	"context"
 	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
-	"strings"
+	k8stesting "k8s.io/client-go/testing"
 	"testing"
 	"time"
 )
 
 var (
+	// ignoreExpiry skips the expiry of results, which is derived from the creation time of the fixtures
+	ignoreExpiry = cmpopts.IgnoreFields(domain.Result{}, "Expires")
+
 	defaultScheme = runtime.NewScheme()
+	defaultMapper = kubernetes.NewRESTMapper(&fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*meta_v1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "configmaps", SingularName: "configmap", Kind: "ConfigMap", Namespaced: true, ShortNames: []string{"cm"}},
+				{Name: "secrets", SingularName: "secret", Kind: "Secret", Namespaced: true},
+				{Name: "services", SingularName: "service", Kind: "Service", Namespaced: true, ShortNames: []string{"svc"}},
+				{Name: "pods", SingularName: "pod", Kind: "Pod", Namespaced: true, ShortNames: []string{"po"}},
+				{Name: "namespaces", SingularName: "namespace", Kind: "Namespace", ShortNames: []string{"ns"}},
+				{Name: "persistentvolumeclaims", SingularName: "persistentvolumeclaim", Kind: "PersistentVolumeClaim", Namespaced: true, ShortNames: []string{"pvc"}},
+				{Name: "persistentvolumes", SingularName: "persistentvolume", Kind: "PersistentVolume", ShortNames: []string{"pv"}},
+				{Name: "serviceaccounts", SingularName: "serviceaccount", Kind: "ServiceAccount", Namespaced: true, ShortNames: []string{"sa"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "deployments", SingularName: "deployment", Kind: "Deployment", Namespaced: true, ShortNames: []string{"deploy"}},
+				{Name: "statefulsets", SingularName: "statefulset", Kind: "StatefulSet", Namespaced: true, ShortNames: []string{"sts"}},
+				{Name: "replicasets", SingularName: "replicaset", Kind: "ReplicaSet", Namespaced: true, ShortNames: []string{"rs"}},
+			},
+		},
+		{
+			GroupVersion: "batch/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "jobs", SingularName: "job", Kind: "Job", Namespaced: true},
+				{Name: "cronjobs", SingularName: "cronjob", Kind: "CronJob", Namespaced: true, ShortNames: []string{"cj"}},
+			},
+		},
+		{
+			GroupVersion: "networking.k8s.io/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "ingresses", SingularName: "ingress", Kind: "Ingress", Namespaced: true, ShortNames: []string{"ing"}},
+			},
+		},
+		{
+			GroupVersion: "autoscaling/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "horizontalpodautoscalers", SingularName: "horizontalpodautoscaler", Kind: "HorizontalPodAutoscaler", Namespaced: true, ShortNames: []string{"hpa"}},
+			},
+		},
+		{
+			GroupVersion: "rbac.authorization.k8s.io/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "rolebindings", SingularName: "rolebinding", Kind: "RoleBinding", Namespaced: true},
+				{Name: "clusterrolebindings", SingularName: "clusterrolebinding", Kind: "ClusterRoleBinding"},
+			},
+		},
+		{
+			GroupVersion: "argoproj.io/v1alpha1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "rollouts", SingularName: "rollout", Kind: "Rollout", Namespaced: true, ShortNames: []string{"ro"}},
+			},
+		},
+		{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "clusterissuers", SingularName: "clusterissuer", Kind: "ClusterIssuer"},
+			},
+		},
+	}}})
 	defaultObjects = []runtime.Object {
 		newDeploymentWithTime("two-hours-deploy", time.Now().Add(-2*time.Hour)),
 		newDeploymentWithTime("eight-hours-deploy", time.Now().Add(-8*time.Hour)),
@@ -26,94 +99,255 @@ var (
 	}
 )
 
-func TestAgeLogOutputAndDeletionUsingDeployments(t *testing.T) {
+func TestAgeResultsAndDeletion(t *testing.T) {
 	tests := []struct {
 		name      string
 		config    domain.Age
-		expected  []string
+		expected  []domain.Result
 		remaining int
+		wantErr   bool
 	}{
 		{
-			name: "Error is printed on invalid resource type",
+			name: "Error is returned on invalid resource type",
 			config: domain.Age{
-				Resources: []string{"invalid-resource"},
-				Namespace: "default",
-				Age:       5 * time.Hour,
-				Allow:     []string{},
-				DryRun:    false,
-			},
-			expected: []string{
-				"Unsupported resource: invalid-resource, skipping.",
+				Resources:  []string{"deployment", "invalid-resource"},
+				Namespaces: []string{"default"},
+				Age:        5 * time.Hour,
+				Allow:      []string{},
+				DryRun:     false,
 			},
+			wantErr:   true,
+			remaining: 8,
 		},
 		{
-			name: "On dry-run, objects are printed and not deleted",
+			name: "On dry-run, objects are returned and not deleted",
 			config: domain.Age{
-				Resources: []string{"deployment"},
-				Namespace: "default",
-				Age:       5 * time.Hour,
-				Allow:     []string{},
-				DryRun:    true,
+				Resources:  []string{"deployment"},
+				Namespaces: []string{"default"},
+				Age:        5 * time.Hour,
+				Allow:      []string{},
+				DryRun:     true,
 			},
-			expected: []string{
-				fmt.Sprintf("eight-hours-deploy\t8h0m0s\tUN-CHANGED (dry-run)"),
-				fmt.Sprintf("seventy-hours-deploy\t70h0m0s\tUN-CHANGED (dry-run)"),
+			expected: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "eight-hours-deploy", Age: 8 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+				{Kind: "deployments", Namespace: "default", Name: "seventy-hours-deploy", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
 			},
+			remaining: 8,
 		},
 		{
-			name: "Objects are printed and deleted",
+			name: "Objects are returned and deleted",
 			config: domain.Age{
-				Resources: []string{"deployment"},
-				Namespace: "default",
-				Age:       5 * time.Hour,
-				Allow:     []string{},
-				DryRun:    false,
+				Resources:  []string{"deployment"},
+				Namespaces: []string{"default"},
+				Age:        5 * time.Hour,
+				Allow:      []string{},
+				DryRun:     false,
 			},
-			expected: []string{
-				fmt.Sprintf("eight-hours-deploy\t8h0m0s\tDELETED"),
-				fmt.Sprintf("seventy-hours-deploy\t70h0m0s\tDELETED"),
+			expected: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "eight-hours-deploy", Age: 8 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
+				{Kind: "deployments", Namespace: "default", Name: "seventy-hours-deploy", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
 			},
+			remaining: 6,
 		},
 		{
 			name: "Deletes multiple resource types",
 			config: domain.Age{
-				Resources: []string{"deploy","svc","ss","job","configmap","secret"},
-				Namespace: "default",
-				Age:       5 * time.Hour,
-				Allow:     []string{},
-				DryRun:    false,
-			},
-			expected: []string{
-				fmt.Sprintf("eight-hours-deploy\t8h0m0s\tDELETED"),
-				fmt.Sprintf("seventy-hours-deploy\t70h0m0s\tDELETED"),
-				fmt.Sprintf("seventy-hours-job\t70h0m0s\tDELETED"),
-				fmt.Sprintf("seventy-hours-ss\t70h0m0s\tDELETED"),
-				fmt.Sprintf("seventy-hours-cm\t70h0m0s\tDELETED"),
-				fmt.Sprintf("seventy-hours-secret\t70h0m0s\tDELETED"),
+				Resources:  []string{"deploy", "svc", "ss", "job", "configmap", "secret"},
+				Namespaces: []string{"default"},
+				Age:        5 * time.Hour,
+				Allow:      []string{},
+				DryRun:     false,
+			},
+			expected: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "eight-hours-deploy", Age: 8 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
+				{Kind: "deployments", Namespace: "default", Name: "seventy-hours-deploy", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
+				{Kind: "services", Namespace: "default", Name: "seventy-hours-svc", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
+				{Kind: "statefulsets", Namespace: "default", Name: "seventy-hours-ss", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
+				{Kind: "jobs", Namespace: "default", Name: "seventy-hours-job", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
+				{Kind: "configmaps", Namespace: "default", Name: "seventy-hours-cm", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
+				{Kind: "secrets", Namespace: "default", Name: "seventy-hours-secret", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Deleted},
 			},
+			remaining: 1,
 		},
 	}
 	for _, tt := range tests {
 		client := fake.NewSimpleDynamicClient(defaultScheme, defaultObjects...)
 
 		t.Run(tt.name, func(t *testing.T) {
-			o := &bytes.Buffer{}
-			err := Age(client, tt.config, o)
-			if err != nil {
-				t.Errorf("Unused() error = %v", err)
+			results, err := Age(client, defaultMapper, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Age() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
-			for _, expected := range tt.expected {
-				if !strings.Contains(o.String(), expected) {
-					t.Errorf("Output error, \nexpected: %s \ngot: %s", expected, o.String())
-					return
-				}
+			if diff := cmp.Diff(results, tt.expected, ignoreExpiry); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+				return
+			}
+
+			if remaining := countObjects(t, client); remaining != tt.remaining {
+				t.Errorf("Age() remaining objects = %d, want %d", remaining, tt.remaining)
 			}
 		})
 	}
 }
 
+func TestAgeAcrossNamespaces(t *testing.T) {
+	other := newDeploymentWithTime("team-a-deploy", time.Now().Add(-70*time.Hour))
+	other.SetNamespace("team-a")
+	client := fake.NewSimpleDynamicClient(defaultScheme, append(defaultObjects, other)...)
+
+	config := domain.Age{
+		Resources:  []string{"deployment"},
+		Namespaces: []string{"default", "team-a"},
+		Age:        24 * time.Hour,
+		Allow:      []string{},
+		DryRun:     true,
+	}
+	expected := []domain.Result{
+		{Kind: "deployments", Namespace: "default", Name: "seventy-hours-deploy", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+		{Kind: "deployments", Namespace: "team-a", Name: "team-a-deploy", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+	}
+
+	results, err := Age(client, defaultMapper, config)
+	if err != nil {
+		t.Errorf("Age() error = %v", err)
+		return
+	}
+
+	if diff := cmp.Diff(results, expected, ignoreExpiry); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestAgeUsesExpiryAnnotations(t *testing.T) {
+	created := time.Now().Add(-8 * time.Hour).UTC().Truncate(time.Second)
+	ttl := newDeploymentWithTime("ttl-deploy", created)
+	ttl.SetAnnotations(map[string]string{kubernetes.TTLAnnotation: "4h"})
+	extended := newDeploymentWithTime("extended-deploy", created)
+	extended.SetAnnotations(map[string]string{kubernetes.ExpiresAtAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)})
+	client := fake.NewSimpleDynamicClient(defaultScheme, ttl, extended, newDeploymentWithTime("default-deploy", created))
+
+	results, err := Age(client, defaultMapper, domain.Age{
+		Resources:  []string{"deployment"},
+		Namespaces: []string{"default"},
+		Age:        24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Age() error = %v", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "deployments", Namespace: "default", Name: "ttl-deploy", Age: 8 * time.Hour, Expires: created.Add(4 * time.Hour), Status: domain.Expired, Action: domain.Deleted},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestAgeResolvesCustomAndClusterScopedResources(t *testing.T) {
+	old := time.Now().Add(-70 * time.Hour)
+	rollout := newResourceWithTime("argoproj.io/v1alpha1", "Rollout", "old-rollout", old)
+	issuer := newResourceWithTime("cert-manager.io/v1", "ClusterIssuer", "old-issuer", old)
+	issuer.SetNamespace("")
+	client := fake.NewSimpleDynamicClient(defaultScheme, rollout, issuer)
+
+	results, err := Age(client, defaultMapper, domain.Age{
+		Resources:  []string{"rollouts.argoproj.io", "ClusterIssuer"},
+		Namespaces: []string{"default", "team-a"},
+		Age:        24 * time.Hour,
+		DryRun:     true,
+	})
+	if err != nil {
+		t.Fatalf("Age() error = %v", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "rollouts", Namespace: "default", Name: "old-rollout", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+		{Kind: "clusterissuers", Name: "old-issuer", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+	}
+	if diff := cmp.Diff(results, expected, ignoreExpiry); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestAgeIdleFor(t *testing.T) {
+	// the stand-in has traffic for the "active" deployment only
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := "0"
+		if strings.Contains(r.URL.Query().Get("query"), `app="active"`) {
+			value = "42"
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1600000000,"` + value + `"]}]}}`))
+	}))
+	defer server.Close()
+
+	old := time.Now().Add(-70 * time.Hour)
+	config := domain.Age{
+		Resources:  []string{"deployment"},
+		Namespaces: []string{"default"},
+		Age:        24 * time.Hour,
+		DryRun:     true,
+		IdleFor:    72 * time.Hour,
+		Prometheus: server.URL,
+		IdleQuery:  `sum(increase(requests{namespace="{{ .Namespace }}",app="{{ .Name }}"}[{{ .Window }}]))`,
+	}
+
+	client := fake.NewSimpleDynamicClient(defaultScheme, newDeploymentWithTime("active", old), newDeploymentWithTime("idle", old))
+	results, err := Age(client, defaultMapper, config)
+	if err != nil {
+		t.Fatalf("Age() error = %v", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "deployments", Namespace: "default", Name: "idle", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun, Details: "idleFor=72h0m0s"},
+	}
+	if diff := cmp.Diff(results, expected, ignoreExpiry); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	config.Resources = []string{"configmap"}
+	if _, err := Age(client, defaultMapper, config); err == nil {
+		t.Error("expected an error using idle-for with configmaps")
+	}
+
+	// queries without samples (i.e. a typo'd metric) don't select anything, rather than every workload
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer empty.Close()
+
+	config.Resources = []string{"deployment"}
+	config.Prometheus = empty.URL
+	if results, err := Age(client, defaultMapper, config); err == nil || len(results) > 0 {
+		t.Errorf("expected an error without results when the query has no samples, got %v", results)
+	}
+
+	// failing queries don't select anything either
+	config.Prometheus = server.URL
+	server.Close()
+	config.Resources = []string{"deployment"}
+	if results, err := Age(client, defaultMapper, config); err == nil || len(results) > 0 {
+		t.Errorf("expected an error without results when prometheus is unavailable, got %v", results)
+	}
+}
+
+// countObjects returns the number of objects remaining across all the resource types used in tests.
+func countObjects(t *testing.T, c dynamic.Interface) int {
+	count := 0
+	for _, gvr := range []schema.GroupVersionResource{
+		kubernetes.PodSchema, kubernetes.ConfigMapSchema, kubernetes.SecretSchema, kubernetes.ServiceSchema,
+		kubernetes.DeploymentSchema, kubernetes.StatefulSetSchema, kubernetes.JobSchema,
+	} {
+		list, err := c.Resource(gvr).Namespace("default").List(context.TODO(), meta_v1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		count += len(list.Items)
+	}
+	return count
+}
+
 func newResourceWithTime(api, kind, name string, t time.Time) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -151,5 +385,3 @@ func newConfigMapWithTime(name string, t time.Time) *unstructured.Unstructured {
 func newSecretWithTime(name string, t time.Time) *unstructured.Unstructured {
 	return newResourceWithTime("v1", "secret", name, t)
 }
-
-
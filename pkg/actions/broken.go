@@ -0,0 +1,59 @@
+package actions
+
+import (
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
+)
+
+// Broken finds the Deployments and StatefulSets which are broken: scaled to zero, or without any available replicas,
+// for longer than 'u.Age', or with pods in CrashLoopBackOff or ImagePullBackOff.
+// Resource types are resolved with the RESTMapper 'm', so any of their names (i.e. "sts") can be used.
+func Broken(c dynamic.Interface, m meta.RESTMapper, u domain.Broken) ([]domain.Result, error) {
+	mappings, err := brokenMappings(m, u.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.Result
+	for i, mapping := range mappings {
+		resource, gvr := u.Resources[i], mapping.Resource
+		for _, namespace := range u.Namespaces {
+			workloads, err := kubernetes.BrokenWorkloads(c, gvr, namespace, u.Age, u.Allow)
+			if err != nil {
+				return results, errors.Wrapf(err, "executing for resource type (%s)", resource)
+			}
+
+			for _, workload := range workloads {
+				result := domain.Result{Kind: gvr.Resource, Namespace: namespace, Name: workload.Name, Age: workload.Age, Status: string(workload.Status), Details: workload.Details}
+				if workload.Protected != "" {
+					results = append(results, protected(result, workload))
+				} else {
+					results = append(results, deleteOrSkip(c, gvr, result, u.DryRun, u.Backup))
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// brokenMappings resolves each of the 'resources' with the RESTMapper 'm', which must be Deployments or StatefulSets.
+func brokenMappings(m meta.RESTMapper, resources []string) ([]*meta.RESTMapping, error) {
+	mappings := make([]*meta.RESTMapping, len(resources))
+	for i, resource := range resources {
+		mapping, err := kubernetes.ResolveResource(m, resource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unsupported resource: %s", resource)
+		}
+
+		gr := mapping.Resource.GroupResource()
+		if gr != kubernetes.DeploymentSchema.GroupResource() && gr != kubernetes.StatefulSetSchema.GroupResource() {
+			return nil, errors.Errorf("unsupported resource: %s", resource)
+		}
+		mappings[i] = mapping
+	}
+	return mappings, nil
+}
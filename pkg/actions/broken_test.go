@@ -0,0 +1,65 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestBroken(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	workload := func(kind, name string, replicas int64) *unstructured.Unstructured {
+		w := newResource("apps/v1", kind, name)
+		w.SetCreationTimestamp(meta_v1.NewTime(created))
+		w.Object["spec"] = map[string]interface{}{
+			"replicas": replicas,
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+		}
+		w.Object["status"] = map[string]interface{}{"availableReplicas": int64(0)}
+		return w
+	}
+	protectedWorkload := workload("Deployment", "paused", 0)
+	protectedWorkload.SetAnnotations(map[string]string{kubernetes.KeepAnnotation: "true"})
+
+	tests := []struct {
+		name     string
+		config   domain.Broken
+		expected []domain.Result
+		wantErr  bool
+	}{
+		{
+			name:   "Broken workloads are deleted, protected workloads are kept",
+			config: domain.Broken{Resources: []string{"deploy", "sts"}, Namespaces: []string{"default"}, Age: 30 * time.Minute},
+			expected: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "scaled-down", Age: time.Hour, Status: "ScaledToZero", Action: domain.Deleted, Details: "since=" + created.UTC().Format(time.RFC3339)},
+				{Kind: "deployments", Namespace: "default", Name: "paused", Age: time.Hour, Status: domain.Protected, Action: domain.Unchanged, Reason: kubernetes.KeepAnnotation, Details: "since=" + created.UTC().Format(time.RFC3339)},
+				{Kind: "statefulsets", Namespace: "default", Name: "db", Age: time.Hour, Status: "Unavailable", Action: domain.Deleted, Details: "since=" + created.UTC().Format(time.RFC3339)},
+			},
+		},
+		{
+			name:    "Error is returned on unsupported resource types",
+			config:  domain.Broken{Resources: []string{"configmap"}, Namespaces: []string{"default"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(defaultScheme, workload("Deployment", "scaled-down", 0), protectedWorkload, workload("StatefulSet", "db", 1))
+			actual, err := Broken(client, defaultMapper, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Broken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if diff := cmp.Diff(actual, tt.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
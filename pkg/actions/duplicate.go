@@ -0,0 +1,51 @@
+package actions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	clientset "k8s.io/client-go/kubernetes"
+)
+
+// Duplicate finds deployments (filtered by the label selector 'filter') in each namespace with a weighted similarity
+// score of at least 'threshold' (0-1), from their 'target' label values, container images, env vars and requests.
+// Duplicates are only reported, so the action is always unchanged.
+func Duplicate(c clientset.Interface, n []string, filter, target string, threshold float64) ([]domain.Result, error) {
+	var results []domain.Result
+	for _, namespace := range n {
+		deployments, err := kubernetes.ListDuplicateDeployments(c, namespace, filter, target, threshold)
+		if err != nil {
+			return results, err
+		}
+
+		names := make([]string, 0, len(deployments))
+		for deployment := range deployments {
+			names = append(names, deployment)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			similar := make([]string, len(deployments[name]))
+			scores := make([]string, len(deployments[name]))
+			for i, s := range deployments[name] {
+				similar[i] = fmt.Sprintf("%s (%.2f)", s.Instance, s.Score)
+				scores[i] = s.String()
+			}
+
+			results = append(results, domain.Result{
+				Kind:      kubernetes.DeploymentSchema.Resource,
+				Namespace: namespace,
+				Name:      name,
+				Status:    domain.Duplicated,
+				Reason:    fmt.Sprintf("similar to: %s", strings.Join(similar, ", ")),
+				Action:    domain.Unchanged,
+				Details:   strings.Join(scores, "; "),
+			})
+		}
+	}
+
+	return results, nil
+}
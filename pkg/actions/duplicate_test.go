@@ -0,0 +1,81 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDuplicateResults(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newLabelledDeployment("default", "app-adam", "adam"),
+		newLabelledDeployment("default", "app-adam2", "adam2"),
+		newLabelledDeployment("default", "app-release", "release"),
+		newLabelledDeployment("team-a", "app-feature", "feature"),
+		newLabelledDeployment("team-a", "app-feature1", "feature1"),
+	)
+
+	expected := []domain.Result{
+		{Kind: "deployments", Namespace: "default", Name: "adam", Status: domain.Duplicated, Reason: "similar to: adam2 (1.00)", Action: domain.Unchanged, Details: "adam2: instance=1.00"},
+		{Kind: "deployments", Namespace: "team-a", Name: "feature", Status: domain.Duplicated, Reason: "similar to: feature1 (1.00)", Action: domain.Unchanged, Details: "feature1: instance=1.00"},
+	}
+
+	results, err := Duplicate(client, []string{"default", "team-a"}, "kubernetes.io/name=app", "kubernetes.io/instance", 0.85)
+	if err != nil {
+		t.Errorf("Duplicate() error = %v", err)
+		return
+	}
+
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestDuplicateScoresContainers(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newDeploymentWithContainer("app-adam", "adam", "registry/app:v1", "ENV=dev"),
+		newDeploymentWithContainer("app-adam2", "adam2", "registry/app:v2", "ENV=dev"),
+		newDeploymentWithContainer("app-john", "john", "registry/app:v1", "ENV=dev"),
+		newDeploymentWithContainer("app-john2", "john2", "registry/other:v1", "ENV=qa"),
+	)
+
+	expected := []domain.Result{
+		{Kind: "deployments", Namespace: "default", Name: "adam", Status: domain.Duplicated, Reason: "similar to: adam2 (0.90)", Action: domain.Unchanged, Details: "adam2: instance=1.00 image=0.70 env=1.00"},
+	}
+
+	results, err := Duplicate(client, []string{"default"}, "kubernetes.io/name=app", "kubernetes.io/instance", 0.85)
+	if err != nil {
+		t.Fatalf("Duplicate() error = %v", err)
+	}
+
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func newDeploymentWithContainer(name, instance, image, env string) runtime.Object {
+	d := newLabelledDeployment("default", name, instance).(*appsv1.Deployment)
+	e := strings.SplitN(env, "=", 2)
+	d.Spec.Template.Spec.Containers = []corev1.Container{{Name: "app", Image: image, Env: []corev1.EnvVar{{Name: e[0], Value: e[1]}}}}
+	return d
+}
+
+func newLabelledDeployment(namespace, name, instance string) runtime.Object {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"kubernetes.io/name":     "app",
+				"kubernetes.io/instance": instance,
+			},
+		},
+	}
+}
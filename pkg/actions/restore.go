@@ -0,0 +1,72 @@
+package actions
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ahstn/karetaker/pkg/backup"
+	"github.com/ahstn/karetaker/pkg/domain"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// Restore re-creates the objects in the backup 'r.Backup' which match 'r.Objects' and 'r.Namespace'.
+// Objects that already exist are left unchanged rather than overwritten.
+func Restore(c dynamic.Interface, r domain.Restore) ([]domain.Result, error) {
+	objects, err := backup.Load(r.Backup)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.Result
+	for _, o := range objects {
+		if (r.Namespace != "" && o.Object.GetNamespace() != r.Namespace) || !matchesAny(o, r.Objects) {
+			continue
+		}
+
+		result := domain.Result{
+			Kind:      o.Resource.Resource,
+			Namespace: o.Object.GetNamespace(),
+			Name:      o.Object.GetName(),
+			Status:    domain.BackedUp,
+		}
+
+		if r.DryRun {
+			result.Action = domain.Unchanged
+			result.Reason = domain.ReasonDryRun
+		} else if _, err := c.Resource(o.Resource).Namespace(result.Namespace).Create(context.TODO(), o.Object, meta_v1.CreateOptions{}); k8serrors.IsAlreadyExists(err) {
+			result.Action = domain.Unchanged
+			result.Reason = domain.ReasonExists
+		} else if err != nil {
+			result.Action = domain.RestoreFailed
+			result.Reason = err.Error()
+		} else {
+			result.Action = domain.Restored
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// matchesAny returns if the object matches one of the "type" or "type/name" selectors 's' (or 's' is empty).
+// Types can be singular or plural resources (i.e. "configmap") or the object's kind (i.e. "ConfigMap").
+func matchesAny(o backup.Object, s []string) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	for _, selector := range s {
+		parts := strings.SplitN(selector, "/", 2)
+		kind := strings.ToLower(parts[0])
+
+		if kind != o.Resource.Resource && kind+"s" != o.Resource.Resource && kind != strings.ToLower(o.Object.GetKind()) {
+			continue
+		}
+		if len(parts) == 1 || parts[1] == o.Object.GetName() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestBackupBeforeDeletionAndRestore(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   domain.Restore
+		existing []string
+		expected []domain.Result
+	}{
+		{
+			name:   "On dry-run, matching objects are returned and not restored",
+			config: domain.Restore{Objects: []string{"configmap"}, DryRun: true},
+			expected: []domain.Result{
+				{Kind: "configmaps", Namespace: "default", Name: unused, Status: domain.BackedUp, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+			},
+		},
+		{
+			name:   "Objects matching a type and name are restored",
+			config: domain.Restore{Objects: []string{"Job/" + failedJob}, Namespace: "default"},
+			expected: []domain.Result{
+				{Kind: "jobs", Namespace: "default", Name: failedJob, Status: domain.BackedUp, Action: domain.Restored},
+			},
+		},
+		{
+			name:     "Existing objects are left unchanged",
+			existing: []string{failedJob},
+			expected: []domain.Result{
+				{Kind: "jobs", Namespace: "default", Name: completedJob, Status: domain.BackedUp, Action: domain.Restored},
+				{Kind: "jobs", Namespace: "default", Name: failedJob, Status: domain.BackedUp, Action: domain.Unchanged, Reason: domain.ReasonExists},
+				{Kind: "configmaps", Namespace: "default", Name: unused, Status: domain.BackedUp, Action: domain.Restored},
+			},
+		},
+		{
+			name:   "Objects from other namespaces are skipped",
+			config: domain.Restore{Namespace: "team-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, dir := backupUnused(t)
+			for _, name := range tt.existing {
+				if _, err := client.Resource(kubernetes.JobSchema).Namespace("default").Create(context.TODO(), newFailedJob(name), meta_v1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.config.Backup = dir
+			results, err := Restore(client, tt.config)
+			if err != nil {
+				t.Errorf("Restore() error = %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(results, tt.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestRestoreStripsServerFields(t *testing.T) {
+	client, dir := backupUnused(t)
+	if _, err := Restore(client, domain.Restore{Backup: dir}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	restored, err := client.Resource(kubernetes.JobSchema).Namespace("default").Get(context.TODO(), completedJob, meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := restored.Object["status"]; found || restored.GetUID() != "" {
+		t.Errorf("Restore() expected server fields to be stripped, got: %v", restored.Object["metadata"])
+	}
+}
+
+// backupUnused deletes the unused configmap and jobs of the default objects, returning the client and backup directory.
+func backupUnused(t *testing.T) (*fake.FakeDynamicClient, string) {
+	dir := t.TempDir()
+	client := fake.NewSimpleDynamicClient(defaultScheme, defaultUnusedObjects...)
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"configmap", "job"},
+		Namespaces: []string{"default"},
+		Allow:      []string{},
+		Backup:     dir,
+	})
+	if err != nil {
+		t.Fatalf("Unused() error = %v", err)
+	}
+	if len(results) != 4 || countObjects(t, client) != 3 {
+		t.Fatalf("Unused() expected 4 results with 3 deletions, got: %v", results)
+	}
+	return client, dir
+}
+
+func TestBackupFailurePreventsDeletion(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(defaultScheme, defaultUnusedObjects...)
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"job"},
+		Namespaces: []string{"default"},
+		Allow:      []string{completedJob},
+		Backup:     "/dev/null/backups",
+	})
+	if err != nil {
+		t.Fatalf("Unused() error = %v", err)
+	}
+
+	if len(results) != 1 || results[0].Action != domain.DeleteFailed || countObjects(t, client) != 6 {
+		t.Errorf("Unused() expected a failed backup to prevent deletion, got: %v", results)
+	}
+}
@@ -0,0 +1,110 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
+)
+
+// Run executes each rule of the (already validated) policy 'p' in order, returning the results of every rule.
+// Rules without their own namespace selection operate in the namespace 'n'.
+// A failing rule doesn't stop the remaining rules, but an error is returned once all rules have run.
+// Resource types are resolved with the RESTMapper 'm' for every rule up front, so none are executed if any can't be.
+func Run(c dynamic.Interface, m meta.RESTMapper, p domain.Policy, n string) ([]domain.RuleResult, error) {
+	var problems []string
+	for _, rule := range p.Rules {
+		if err := resolveRule(m, rule); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", rule.Name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("invalid policy:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	var results []domain.RuleResult
+	failed := 0
+
+	for _, rule := range p.Rules {
+		result := domain.RuleResult{Rule: rule.Name, Operation: rule.Operation}
+
+		var err error
+		result.Namespaces, err = ruleNamespaces(c, rule, n)
+		if err == nil {
+			result.Results, err = runRule(c, m, rule, result.Namespaces, p.Backup)
+		}
+
+		if err != nil {
+			failed++
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	if failed > 0 {
+		return results, errors.Errorf("%d of %d rules failed", failed, len(p.Rules))
+	}
+	return results, nil
+}
+
+func runRule(c dynamic.Interface, m meta.RESTMapper, r domain.Rule, n []string, b string) ([]domain.Result, error) {
+	switch r.Operation {
+	case domain.AgeOperation:
+		config, err := r.AgeConfig(n)
+		if err != nil {
+			return nil, err
+		}
+		config.Backup = b
+		return Age(c, m, config)
+	case domain.UnusedOperation:
+		config, err := r.UnusedConfig(n)
+		if err != nil {
+			return nil, err
+		}
+		config.Backup = b
+		return Unused(c, m, config)
+	case domain.BrokenOperation:
+		config, err := r.BrokenConfig(n)
+		if err != nil {
+			return nil, err
+		}
+		config.Backup = b
+		return Broken(c, m, config)
+	default:
+		return nil, errors.Errorf("unsupported operation %q", r.Operation)
+	}
+}
+
+// resolveRule resolves the resources of the rule 'r' with the RESTMapper 'm', checking its operation supports them.
+func resolveRule(m meta.RESTMapper, r domain.Rule) error {
+	var err error
+	switch r.Operation {
+	case domain.AgeOperation:
+		_, err = ageMappings(m, r.Resources, r.IdleFor != "")
+	case domain.UnusedOperation:
+		_, _, err = unusedHandlers(m, r.Resources)
+	case domain.BrokenOperation:
+		_, err = brokenMappings(m, r.Resources)
+	default:
+		err = errors.Errorf("unsupported operation %q", r.Operation)
+	}
+	return err
+}
+
+// ruleNamespaces resolves the namespaces a rule operates in, excluding the system namespaces
+// when selecting multiple namespaces.
+func ruleNamespaces(c dynamic.Interface, r domain.Rule, n string) ([]string, error) {
+	if r.AllNamespaces || r.NamespaceSelector != "" {
+		exclude := append(append([]string{}, kubernetes.SystemNamespaces...), r.ExcludeNamespaces...)
+		return kubernetes.SelectNamespaces(c, r.NamespaceSelector, exclude)
+	}
+
+	if len(r.Namespaces) > 0 {
+		return r.Namespaces, nil
+	}
+	return []string{n}, nil
+}
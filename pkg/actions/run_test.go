@@ -0,0 +1,122 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestRunExecutesRulesInOrder(t *testing.T) {
+	dryRun := false
+	policy := domain.Policy{
+		Rules: []domain.Rule{
+			{Name: "old-deploys", Operation: domain.AgeOperation, Resources: []string{"deploy"}, Age: "24h"},
+			{Name: "unused-configs", Operation: domain.UnusedOperation, Resources: []string{"configmap"}, DryRun: &dryRun},
+			{Name: "other-namespace", Operation: domain.AgeOperation, Resources: []string{"job"}, Age: "1h", Namespaces: []string{"team-a"}},
+		},
+	}
+
+	objects := append([]runtime.Object{}, defaultObjects...)
+	client := fake.NewSimpleDynamicClient(defaultScheme, append(objects, defaultUnusedObjects...)...)
+
+	expected := []domain.RuleResult{
+		{
+			Rule:       "old-deploys",
+			Operation:  domain.AgeOperation,
+			Namespaces: []string{"default"},
+			Results: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "seventy-hours-deploy", Age: 70 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+			},
+		},
+		{
+			Rule:       "unused-configs",
+			Operation:  domain.UnusedOperation,
+			Namespaces: []string{"default"},
+			Results: []domain.Result{
+				{Kind: "configmaps", Namespace: "default", Name: "seventy-hours-cm", Age: 0, Status: domain.NotInUse, Action: domain.Deleted},
+				{Kind: "configmaps", Namespace: "default", Name: unused, Status: domain.NotInUse, Action: domain.Deleted},
+				{Kind: "configmaps", Namespace: "default", Name: usedConfigName, Status: domain.InUse, Action: domain.Unchanged, Reason: "in-use by pods/config-pod"},
+			},
+		},
+		{
+			Rule:       "other-namespace",
+			Operation:  domain.AgeOperation,
+			Namespaces: []string{"team-a"},
+		},
+	}
+
+	results, err := Run(client, defaultMapper, policy, "default")
+	if err != nil {
+		t.Errorf("Run() error = %v", err)
+		return
+	}
+
+	if diff := cmp.Diff(results, expected, ignoreExpiry); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestRunContinuesAfterFailingRule(t *testing.T) {
+	policy := domain.Policy{
+		Rules: []domain.Rule{
+			{Name: "invalid-age", Operation: domain.AgeOperation, Resources: []string{"deploy"}, Age: "soon"},
+			{Name: "old-deploys", Operation: domain.AgeOperation, Resources: []string{"deploy"}, Age: "5h"},
+		},
+	}
+	client := fake.NewSimpleDynamicClient(defaultScheme, newDeploymentWithTime("eight-hours-deploy", time.Now().Add(-8*time.Hour)))
+
+	expected := []domain.RuleResult{
+		{
+			Rule:       "invalid-age",
+			Operation:  domain.AgeOperation,
+			Namespaces: []string{"default"},
+			Error:      `unsupported duration: time: invalid duration "soon"`,
+		},
+		{
+			Rule:       "old-deploys",
+			Operation:  domain.AgeOperation,
+			Namespaces: []string{"default"},
+			Results: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "eight-hours-deploy", Age: 8 * time.Hour, Status: domain.Expired, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+			},
+		},
+	}
+
+	results, err := Run(client, defaultMapper, policy, "default")
+	if err == nil || err.Error() != "1 of 2 rules failed" {
+		t.Errorf("Run() error = %v, want 1 of 2 rules failed", err)
+	}
+
+	if diff := cmp.Diff(results, expected, ignoreExpiry); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestRunResolvesEveryRuleFirst(t *testing.T) {
+	dryRun := false
+	policy := domain.Policy{
+		Rules: []domain.Rule{
+			{Name: "unused-configs", Operation: domain.UnusedOperation, Resources: []string{"configmap"}, DryRun: &dryRun},
+			{Name: "typo", Operation: domain.AgeOperation, Resources: []string{"deploymnet"}, Age: "24h", DryRun: &dryRun},
+			{Name: "broken-configs", Operation: domain.BrokenOperation, Resources: []string{"configmap"}},
+		},
+	}
+	client := fake.NewSimpleDynamicClient(defaultScheme, defaultUnusedObjects...)
+
+	results, err := Run(client, defaultMapper, policy, "default")
+	if err == nil || !strings.Contains(err.Error(), "typo: unsupported resource: deploymnet") || !strings.Contains(err.Error(), "broken-configs: unsupported resource: configmap") {
+		t.Errorf("Run() error = %v, want every unsupported resource", err)
+	}
+
+	if len(results) > 0 {
+		t.Errorf("Run() executed rules: %v", results)
+	}
+	if remaining := countObjects(t, client); remaining != len(defaultUnusedObjects) {
+		t.Errorf("Run() remaining objects = %d, want %d", remaining, len(defaultUnusedObjects))
+	}
+}
@@ -2,98 +2,362 @@ package actions
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/ahstn/karetaker/pkg/domain"
 	"github.com/ahstn/karetaker/pkg/kubernetes"
-	"io"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 )
 
 // Unused retrieves the resources in use (i.e. referenced configmaps) and all the existing resources.
 // It then cross-references those to determine which are not currently in use.
-func Unused(c dynamic.Interface, u domain.Unused, o io.Writer) error {
-	for _, resource := range u.Resources {
-		var err error
-		switch resource {
-		case "configmap","configmaps","secret","secrets":
-			err = handleConfigSecrets(c, u, o)
-		case "job","jobs":
-			err = handleJobs(c, u, o)
-		default:
-			fmt.Fprintf(o, "Unsupported resource: %s, skipping.", resource)
-			continue
+// Resource types are resolved with the RESTMapper 'm', so any of their names (i.e. "cm") can be used.
+// Every type is resolved before any is handled, so an unsupported type never follows deletions.
+func Unused(c dynamic.Interface, m meta.RESTMapper, u domain.Unused) ([]domain.Result, error) {
+	mappings, handlers, err := unusedHandlers(m, u.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.Result
+	for i, mapping := range mappings {
+		namespaces := u.Namespaces
+		if !kubernetes.IsNamespaced(mapping) {
+			namespaces = []string{""}
 		}
 
+		for _, namespace := range namespaces {
+			r, err := handlers[i](c, u, namespace)
+			results = append(results, r...)
+			if err != nil {
+				return results, errors.Wrapf(err, "executing for resource type (%s)", u.Resources[i])
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// unusedHandler finds (and deletes) the unused objects of a single resource type in the namespace 'n'.
+type unusedHandler func(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error)
+
+// unusedHandlers resolves each of the 'resources' with the RESTMapper 'm' and picks the handler of its type.
+func unusedHandlers(m meta.RESTMapper, resources []string) ([]*meta.RESTMapping, []unusedHandler, error) {
+	mappings := make([]*meta.RESTMapping, len(resources))
+	handlers := make([]unusedHandler, len(resources))
+	for i, resource := range resources {
+		mapping, err := kubernetes.ResolveResource(m, resource)
 		if err != nil {
-			fmt.Printf("error executing for resource type (%s), continuing...", resource)
+			return nil, nil, errors.Wrapf(err, "unsupported resource: %s", resource)
 		}
+
+		var handler unusedHandler
+		switch mapping.Resource.GroupResource() {
+		case kubernetes.ConfigMapSchema.GroupResource():
+			handler = handleConfigs
+		case kubernetes.SecretSchema.GroupResource():
+			handler = handleSecrets
+		case kubernetes.JobSchema.GroupResource():
+			handler = handleJobs
+		case kubernetes.CronJobSchema.GroupResource():
+			gvr := mapping.Resource
+			handler = func(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+				return handleCronJobs(c, gvr, u, n)
+			}
+		case kubernetes.PodSchema.GroupResource():
+			handler = handlePods
+		case kubernetes.ServiceSchema.GroupResource():
+			handler = handleServices
+		case kubernetes.PersistentVolumeClaimSchema.GroupResource():
+			handler = handleClaims
+		case kubernetes.PersistentVolumeSchema.GroupResource():
+			handler = handleVolumes
+		case kubernetes.ServiceAccountSchema.GroupResource():
+			handler = handleServiceAccounts
+		case kubernetes.RoleBindingSchema.GroupResource():
+			handler = handleRoleBindings
+		case kubernetes.ClusterRoleBindingSchema.GroupResource():
+			handler = handleClusterRoleBindings
+		case kubernetes.ReplicaSetSchema.GroupResource():
+			handler = handleReplicaSets
+		case kubernetes.IngressSchema.GroupResource():
+			gvr := mapping.Resource
+			handler = func(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+				return handleIngresses(c, gvr, u, n)
+			}
+		case kubernetes.HorizontalPodAutoscalerSchema.GroupResource():
+			handler = func(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+				return handleAutoscalers(c, m, u, n)
+			}
+		default:
+			return nil, nil, errors.Errorf("unsupported resource: %s", resource)
+		}
+
+		mappings[i] = mapping
+		handlers[i] = handler
 	}
+	return mappings, handlers, nil
+}
 
-	return nil
+func handleConfigs(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	configs, _, err := kubernetes.UsedConfigAndSecrets(c, n)
+	if err != nil {
+		return nil, err
+	}
+	return handleReferenced(c, u, n, kubernetes.ConfigMapSchema, configs)
 }
 
-func handleConfigSecrets(c dynamic.Interface, u domain.Unused, o io.Writer) error {
-	usedConfigs, usedSecrets, err := kubernetes.UsedConfigAndSecrets(c, u.Namespace)
+func handleSecrets(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	_, secrets, err := kubernetes.UsedConfigAndSecrets(c, n)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, resource := range u.Resources {
-		var gvr schema.GroupVersionResource
-		var ref map[string]bool
+	return handleReferenced(c, u, n, kubernetes.SecretSchema, secrets)
+}
 
-		switch resource {
-		case "configmap", "configmaps":
-			gvr = kubernetes.ConfigMapSchema
-			ref = usedConfigs
-		case "secret", "secrets":
-			gvr = kubernetes.SecretSchema
-			ref = usedSecrets
-		default:
-			return nil
+// handleReferenced deletes the objects of type 'gvr' which aren't present in the references 'ref'.
+// Objects in use record what references them in the reason.
+func handleReferenced(c dynamic.Interface, u domain.Unused, n string, gvr schema.GroupVersionResource, ref kubernetes.References) ([]domain.Result, error) {
+	list, err := kubernetes.Resources(c, gvr, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+	return referencedResults(c, u, n, gvr, list, ref, false), nil
+}
+
+// handleServices deletes the Services whose selector matches no pods or workload templates, and which have no endpoints,
+// which are older than 'u.Age' (as a new Service has no endpoints until its workload is rolled out).
+func handleServices(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	services, err := kubernetes.SelectorServices(c, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := kubernetes.UsedServices(c, n)
+	if err != nil {
+		return nil, err
+	}
+	return referencedResults(c, u, n, kubernetes.ServiceSchema, services, used, true), nil
+}
+
+// handleClaims deletes the persistent volume claims not mounted by any pod or workload template, which are older than 'u.Age'.
+func handleClaims(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	claims, err := kubernetes.PersistentVolumeClaims(c, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := kubernetes.UsedPersistentVolumeClaims(c, n)
+	if err != nil {
+		return nil, err
+	}
+	return referencedResults(c, u, n, kubernetes.PersistentVolumeClaimSchema, claims, used, true), nil
+}
+
+// handleVolumes deletes the persistent volumes which have been released from their claim (or failed).
+func handleVolumes(c dynamic.Interface, u domain.Unused, _ string) ([]domain.Result, error) {
+	volumes, err := kubernetes.ReleasedPersistentVolumes(c, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.Result
+	for _, volume := range volumes {
+		result := domain.Result{Kind: volume.Kind, Name: volume.Name, Age: volume.Age, Status: string(volume.Status), Details: volume.Details}
+		if volume.Protected != "" {
+			results = append(results, protected(result, volume))
+		} else if u.Age != 0 && (volume.Age < u.Age) {
+			result.Action = domain.Unchanged
+			result.Reason = domain.ReasonAge
+			results = append(results, result)
+		} else {
+			results = append(results, deleteOrSkip(c, kubernetes.PersistentVolumeSchema, result, u.DryRun, u.Backup))
 		}
+	}
+	return results, nil
+}
 
-		list, err := kubernetes.Resources(c, gvr, u.Namespace, u.Allow)
-		if err != nil {
-			return err
+// handleServiceAccounts deletes the ServiceAccounts not used by any pod or workload template, which are older than 'u.Age'.
+func handleServiceAccounts(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	accounts, err := kubernetes.ServiceAccounts(c, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := kubernetes.UsedServiceAccounts(c, n)
+	if err != nil {
+		return nil, err
+	}
+	return referencedResults(c, u, n, kubernetes.ServiceAccountSchema, accounts, used, true), nil
+}
+
+// handleRoleBindings deletes the RoleBindings whose role or subjects no longer exist, which are older than 'u.Age'
+// (as bindings are often created ahead of their ServiceAccounts).
+func handleRoleBindings(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	bindings, err := kubernetes.OrphanedRoleBindings(c, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+	return orphanedResults(c, u, n, kubernetes.RoleBindingSchema, bindings, true), nil
+}
+
+// handleClusterRoleBindings deletes the ClusterRoleBindings whose role or subjects no longer exist, which are older than 'u.Age'.
+func handleClusterRoleBindings(c dynamic.Interface, u domain.Unused, _ string) ([]domain.Result, error) {
+	bindings, err := kubernetes.OrphanedClusterRoleBindings(c, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+	return orphanedResults(c, u, "", kubernetes.ClusterRoleBindingSchema, bindings, true), nil
+}
+
+// handleIngresses deletes the Ingresses whose backend Services no longer exist.
+func handleIngresses(c dynamic.Interface, gvr schema.GroupVersionResource, u domain.Unused, n string) ([]domain.Result, error) {
+	ingresses, err := kubernetes.OrphanedIngresses(c, gvr, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+	return orphanedResults(c, u, n, gvr, ingresses, false), nil
+}
+
+// handleAutoscalers deletes the HorizontalPodAutoscalers whose scale target no longer exists.
+func handleAutoscalers(c dynamic.Interface, m meta.RESTMapper, u domain.Unused, n string) ([]domain.Result, error) {
+	autoscalers, err := kubernetes.OrphanedAutoscalers(c, m, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+	return orphanedResults(c, u, n, kubernetes.HorizontalPodAutoscalerSchema, autoscalers, false), nil
+}
+
+// handleReplicaSets deletes the old ReplicaSet revisions scaled to zero (beyond 'u.KeepRevisions' per Deployment),
+// and the ReplicaSets whose Deployment no longer exists.
+func handleReplicaSets(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	replicaSets, err := kubernetes.StaleReplicaSets(c, n, u.KeepRevisions, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+	return orphanedResults(c, u, n, kubernetes.ReplicaSetSchema, replicaSets, false), nil
+}
+
+// orphanedResults deletes the objects of type 'gvr' in 'list', which are no longer needed or reference objects that no longer exist.
+// Any missing objects are included in the status, i.e. "MISSING clusterroles/admin".
+// When 'age' is set, objects younger than 'u.Age' are left unchanged.
+func orphanedResults(c dynamic.Interface, u domain.Unused, n string, gvr schema.GroupVersionResource, list []kubernetes.Resource, age bool) []domain.Result {
+	var results []domain.Result
+	for _, item := range list {
+		status := domain.NotInUse
+		if len(item.Missing) > 0 {
+			status = fmt.Sprintf("%s %s", domain.Missing, strings.Join(item.Missing, ","))
+		}
+		result := domain.Result{Kind: gvr.Resource, Namespace: n, Name: item.Name, Status: status, Details: item.Details}
+		if age {
+			result.Age = item.Age
 		}
 
-		fmt.Fprintf(o, "RESOURCE (%s)\tSTATUS\n", resource)
-		for _, item := range list {
-			if _, isPresent := ref[item]; isPresent {
-				fmt.Fprintf(o, "%s\tIN-USE\t\n", item)
-			} else if u.DryRun {
-				fmt.Fprintf(o, "%s\tUN-CHANGED (dry-run)\t\n", item)
-			} else {
-				fmt.Fprintf(o, "%s\tDELETED\t\n", item)
-				err = kubernetes.DeleteResource(c, gvr, u.Namespace, item)
-				if err != nil {
-					fmt.Printf("error deleting %s, continuing...", item)
-				}
-			}
+		if item.Protected != "" {
+			results = append(results, protected(result, item))
+		} else if age && u.Age != 0 && (item.Age < u.Age) {
+			result.Action = domain.Unchanged
+			result.Reason = domain.ReasonAge
+			results = append(results, result)
+		} else {
+			results = append(results, deleteOrSkip(c, gvr, result, u.DryRun, u.Backup))
+		}
+	}
+	return results
+}
+
+// referencedResults deletes the objects of type 'gvr' in 'list' which aren't present in the references 'ref'.
+// When 'age' is set, unreferenced objects younger than 'u.Age' are left unchanged.
+func referencedResults(c dynamic.Interface, u domain.Unused, n string, gvr schema.GroupVersionResource, list []kubernetes.Resource, ref kubernetes.References, age bool) []domain.Result {
+	var results []domain.Result
+	for _, item := range list {
+		result := domain.Result{Kind: gvr.Resource, Namespace: n, Name: item.Name, Status: domain.NotInUse, Details: item.Details}
+		if age {
+			result.Age = item.Age
+		}
+
+		if item.Protected != "" {
+			results = append(results, protected(result, item))
+		} else if owners, isPresent := ref[item.Name]; isPresent {
+			result.Status = domain.InUse
+			result.Action = domain.Unchanged
+			result.Reason = fmt.Sprintf("%s by %s", domain.ReasonInUse, strings.Join(owners, ", "))
+			results = append(results, result)
+		} else if age && u.Age != 0 && (item.Age < u.Age) {
+			result.Action = domain.Unchanged
+			result.Reason = domain.ReasonAge
+			results = append(results, result)
+		} else {
+			results = append(results, deleteOrSkip(c, gvr, result, u.DryRun, u.Backup))
 		}
 	}
-	return nil
+	return results
 }
 
-func handleJobs(c dynamic.Interface, u domain.Unused, o io.Writer) error {
-	jobs, err := kubernetes.JobsNotRunning(c, u.Namespace, u.Allow)
+// handleJobs deletes the completed and failed Jobs, keeping the history of each CronJob (see kubernetes.CronJobHistory).
+func handleJobs(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	jobs, err := kubernetes.JobsNotRunning(c, n, u.Allow)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Fprintf(o, "RESOURCE (jobs)\tSTATUS\n")
-	for _, job := range jobs {
-		if u.DryRun {
-			fmt.Fprintf(o, "%s\tUN-CHANGED (dry-run)\t\n", job.Name)
-		} else if u.Age != 0 && (job.Age < u.Age) {
-			fmt.Fprintf(o, "%s\tUN-CHANGED (age)\t\n", job.Name)
-		}else {
-			fmt.Fprintf(o, "%s\tDELETED (was: %v)\t\n", job.Name, job.Status)
-			err = kubernetes.DeleteResource(c, kubernetes.JobSchema, u.Namespace, job.Name)
-			if err != nil {
-				fmt.Printf("error deleting %s, continuing...", job.Name)
-			}
+	history, err := kubernetes.CronJobHistory(c, n, u.KeepSuccessful, u.KeepFailed)
+	if err != nil {
+		return nil, err
+	}
+	return finishedResults(c, u, n, kubernetes.JobSchema, jobs, history), nil
+}
+
+// handleCronJobs deletes the CronJobs of type 'gvr' which are suspended, or haven't succeeded within 'u.SuccessWindow'.
+func handleCronJobs(c dynamic.Interface, gvr schema.GroupVersionResource, u domain.Unused, n string) ([]domain.Result, error) {
+	cronJobs, err := kubernetes.StaleCronJobs(c, n, u.SuccessWindow, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.Result
+	for _, cronJob := range cronJobs {
+		result := domain.Result{Kind: gvr.Resource, Namespace: n, Name: cronJob.Name, Status: string(cronJob.Status), Details: cronJob.Details}
+		if cronJob.Protected != "" {
+			results = append(results, protected(result, cronJob))
+		} else {
+			results = append(results, deleteOrSkip(c, gvr, result, u.DryRun, u.Backup))
+		}
+	}
+	return results, nil
+}
+
+// handlePods deletes the pods which have terminated (i.e. evicted, completed or failed pods), unless owned by an active Job.
+func handlePods(c dynamic.Interface, u domain.Unused, n string) ([]domain.Result, error) {
+	pods, err := kubernetes.FinishedPods(c, n, u.Allow)
+	if err != nil {
+		return nil, err
+	}
+	return finishedResults(c, u, n, kubernetes.PodSchema, pods, nil), nil
+}
+
+// finishedResults deletes the objects of type 'gvr' in 'list' which have finished, and are older than 'u.Age'.
+// Objects in 'history' are kept, recording what they're the history of in the reason.
+func finishedResults(c dynamic.Interface, u domain.Unused, n string, gvr schema.GroupVersionResource, list []kubernetes.Resource, history kubernetes.References) []domain.Result {
+	var results []domain.Result
+	for _, item := range list {
+		result := domain.Result{Kind: item.Kind, Namespace: n, Name: item.Name, Age: item.Age, Status: string(item.Status)}
+		if item.Protected != "" {
+			results = append(results, protected(result, item))
+		} else if owners, isPresent := history[item.Name]; isPresent {
+			result.Action = domain.Unchanged
+			result.Reason = fmt.Sprintf("%s of %s", domain.ReasonHistory, strings.Join(owners, ", "))
+			results = append(results, result)
+		} else if u.Age != 0 && (item.Age < u.Age) {
+			result.Action = domain.Unchanged
+			result.Reason = domain.ReasonAge
+			results = append(results, result)
+		} else {
+			results = append(results, deleteOrSkip(c, gvr, result, u.DryRun, u.Backup))
 		}
 	}
-	return nil
-}
\ No newline at end of file
+	return results
+}
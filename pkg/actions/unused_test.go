@@ -1,13 +1,17 @@
 package actions
 
 import (
-	"bytes"
-	"fmt"
+	"context"
 	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/google/go-cmp/cmp"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/dynamic/fake"
-	"strings"
+	k8stesting "k8s.io/client-go/testing"
 	"testing"
 	"time"
 )
@@ -31,89 +35,450 @@ var (
 	}
 )
 
-func TestUnusedLogOutputAndDeletion(t *testing.T) {
+func TestUnusedResultsAndDeletion(t *testing.T) {
 	tests := []struct {
 		name      string
 		config    domain.Unused
-		expected  []string
+		expected  []domain.Result
 		remaining int
+		wantErr   bool
 	}{
 		{
-			name: "Error is printed on invalid resource type",
+			name: "Error is returned on invalid resource type",
 			config: domain.Unused{
-				Resources: []string{"invalid-resource"},
-				Namespace: "default",
-				Allow:     []string{},
-				DryRun:    false,
+				Resources:  []string{"invalid-resource"},
+				Namespaces: []string{"default"},
+				Allow:      []string{},
+				DryRun:     false,
 			},
-			expected: []string{
-				"Unsupported resource: invalid-resource, skipping.",
+			wantErr:   true,
+			remaining: 6,
+		},
+		{
+			name: "Nothing is deleted when an unsupported type follows a valid one",
+			config: domain.Unused{
+				Resources:  []string{"configmap", "deployment"},
+				Namespaces: []string{"default"},
+				Allow:      []string{},
+				DryRun:     false,
 			},
+			wantErr:   true,
+			remaining: 6,
 		},
 		{
-			name: "On dry-run, objects are printed and not deleted",
+			name: "On dry-run, objects are returned and not deleted",
 			config: domain.Unused{
-				Resources: []string{"configmap", "job"},
-				Namespace: "default",
-				Allow:     []string{},
-				DryRun:    true,
+				Resources:  []string{"configmap", "job"},
+				Namespaces: []string{"default"},
+				Allow:      []string{},
+				DryRun:     true,
 			},
-			expected: []string{
-				fmt.Sprintf("%s\tUN-CHANGED (dry-run)", unused),
-				fmt.Sprintf("%s\tIN-USE", usedConfigName),
-				fmt.Sprintf("%s\tUN-CHANGED (dry-run)", failedJob),
-				fmt.Sprintf("%s\tUN-CHANGED (dry-run)", completedJob),
+			expected: []domain.Result{
+				{Kind: "configmaps", Namespace: "default", Name: unused, Status: domain.NotInUse, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+				{Kind: "configmaps", Namespace: "default", Name: usedConfigName, Status: domain.InUse, Action: domain.Unchanged, Reason: "in-use by pods/config-pod"},
+				{Kind: "jobs", Namespace: "default", Name: failedJob, Status: "Failed", Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+				{Kind: "jobs", Namespace: "default", Name: completedJob, Age: time.Hour, Status: "Completed", Action: domain.Unchanged, Reason: domain.ReasonDryRun},
 			},
+			remaining: 6,
 		},
 		{
 			name: "With age filter, certain objects are skipped",
 			config: domain.Unused{
-				Resources: []string{"configmap", "job"},
-				Namespace: "default",
-				Age:       24 * time.Hour,
-				Allow:     []string{},
-				DryRun:    false,
+				Resources:  []string{"job"},
+				Namespaces: []string{"default"},
+				Age:        24 * time.Hour,
+				Allow:      []string{},
+				DryRun:     false,
 			},
-			expected: []string{
-				fmt.Sprintf("%s\tUN-CHANGED (age)", completedJob),
+			expected: []domain.Result{
+				{Kind: "jobs", Namespace: "default", Name: failedJob, Status: "Failed", Action: domain.Unchanged, Reason: domain.ReasonAge},
+				{Kind: "jobs", Namespace: "default", Name: completedJob, Age: time.Hour, Status: "Completed", Action: domain.Unchanged, Reason: domain.ReasonAge},
 			},
+			remaining: 6,
 		},
 		{
-			name: "Objects are printed and deleted",
+			name: "Objects are returned and deleted",
 			config: domain.Unused{
-				Resources: []string{"configmap", "job"},
-				Namespace: "default",
-				Allow:     []string{},
-				DryRun:    false,
+				Resources:  []string{"configmap", "secret", "job"},
+				Namespaces: []string{"default"},
+				Allow:      []string{},
+				DryRun:     false,
 			},
-			expected: []string{
-				fmt.Sprintf("%s\tDELETED", unused),
-				fmt.Sprintf("%s\tIN-USE", usedConfigName),
-				fmt.Sprintf("%s\tDELETED (was: Failed)", failedJob),
+			expected: []domain.Result{
+				{Kind: "configmaps", Namespace: "default", Name: unused, Status: domain.NotInUse, Action: domain.Deleted},
+				{Kind: "configmaps", Namespace: "default", Name: usedConfigName, Status: domain.InUse, Action: domain.Unchanged, Reason: "in-use by pods/config-pod"},
+				{Kind: "secrets", Namespace: "default", Name: usedSecretName, Status: domain.InUse, Action: domain.Unchanged, Reason: "in-use by pods/config-pod"},
+				{Kind: "jobs", Namespace: "default", Name: failedJob, Status: "Failed", Action: domain.Deleted},
+				{Kind: "jobs", Namespace: "default", Name: completedJob, Age: time.Hour, Status: "Completed", Action: domain.Deleted},
 			},
+			remaining: 3,
 		},
 	}
 	for _, tt := range tests {
 		client := fake.NewSimpleDynamicClient(defaultScheme, defaultUnusedObjects...)
 
 		t.Run(tt.name, func(t *testing.T) {
-			o := &bytes.Buffer{}
-			err := Unused(client, tt.config, o)
-			if err != nil {
-				t.Errorf("Unused() error = %v", err)
+			results, err := Unused(client, defaultMapper, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unused() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
-			for _, expected := range tt.expected {
-				if !strings.Contains(o.String(), expected) {
-					t.Errorf("Output error, \nexpected: %s \ngot: %s", expected, o.String())
-					return
-				}
+			if diff := cmp.Diff(results, tt.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+				return
+			}
+
+			if remaining := countObjects(t, client); remaining != tt.remaining {
+				t.Errorf("Unused() remaining objects = %d, want %d", remaining, tt.remaining)
 			}
 		})
 	}
 }
 
+func TestUnusedSkipsProtectedObjects(t *testing.T) {
+	kept := newConfigmap("kept-config")
+	kept.SetLabels(map[string]string{kubernetes.KeepAnnotation: "true"})
+	held := newFailedJob("held-job")
+	held.SetAnnotations(map[string]string{kubernetes.KeepUntilAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)})
+	client := fake.NewSimpleDynamicClient(defaultScheme, kept, held, newConfigmap(unused))
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"configmap", "job"},
+		Namespaces: []string{"default"},
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "configmaps", Namespace: "default", Name: "kept-config", Status: domain.Protected, Action: domain.Unchanged, Reason: kubernetes.KeepAnnotation},
+		{Kind: "configmaps", Namespace: "default", Name: unused, Status: domain.NotInUse, Action: domain.Deleted},
+		{Kind: "jobs", Namespace: "default", Name: "held-job", Status: domain.Protected, Action: domain.Unchanged, Reason: "karetaker.io/keep-until " + held.GetAnnotations()[kubernetes.KeepUntilAnnotation]},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestUnusedVolumes(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	pod := newResource("v1", "Pod", "app")
+	pod.Object["spec"] = map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "app"}},
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "app-data"}},
+		},
+	}
+
+	claim := func(name string, created time.Time) *unstructured.Unstructured {
+		pvc := newResourceWithTime("v1", "PersistentVolumeClaim", name, created)
+		pvc.Object["spec"] = map[string]interface{}{"storageClassName": "standard"}
+		pvc.Object["status"] = map[string]interface{}{"capacity": map[string]interface{}{"storage": "1Gi"}}
+		return pvc
+	}
+	volume := func(name, phase string) *unstructured.Unstructured {
+		pv := newResourceWithTime("v1", "PersistentVolume", name, old)
+		pv.SetNamespace("")
+		pv.Object["spec"] = map[string]interface{}{"capacity": map[string]interface{}{"storage": "10Gi"}, "storageClassName": "ssd"}
+		pv.Object["status"] = map[string]interface{}{"phase": phase}
+		return pv
+	}
+
+	client := fake.NewSimpleDynamicClient(defaultScheme, pod,
+		claim("app-data", old), claim("orphaned", old), claim("new", time.Now()),
+		volume("pvc-bound", "Bound"), volume("pvc-released", "Released"))
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"pvc", "pv"},
+		Namespaces: []string{"default", "team-a"},
+		Age:        24 * time.Hour,
+		DryRun:     true,
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "persistentvolumeclaims", Namespace: "default", Name: "app-data", Age: 48 * time.Hour, Status: domain.InUse, Action: domain.Unchanged, Reason: "in-use by pods/app", Details: "capacity=1Gi storageClass=standard"},
+		{Kind: "persistentvolumeclaims", Namespace: "default", Name: "orphaned", Age: 48 * time.Hour, Status: domain.NotInUse, Action: domain.Unchanged, Reason: domain.ReasonDryRun, Details: "capacity=1Gi storageClass=standard"},
+		{Kind: "persistentvolumeclaims", Namespace: "default", Name: "new", Status: domain.NotInUse, Action: domain.Unchanged, Reason: domain.ReasonAge, Details: "capacity=1Gi storageClass=standard"},
+		{Kind: "persistentvolumes", Name: "pvc-released", Age: 48 * time.Hour, Status: "Released", Action: domain.Unchanged, Reason: domain.ReasonDryRun, Details: "capacity=10Gi storageClass=ssd"},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestUnusedServices(t *testing.T) {
+	pod := newResource("v1", "Pod", "web-x2v9")
+	pod.SetLabels(map[string]string{"app": "web"})
+	pod.Object["spec"] = map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "web"}}}
+
+	old := time.Now().Add(-48 * time.Hour)
+	service := func(name string, created time.Time, spec map[string]interface{}) *unstructured.Unstructured {
+		svc := newResourceWithTime("v1", "Service", name, created)
+		svc.Object["spec"] = spec
+		return svc
+	}
+
+	// a new service has no endpoints until its workload is rolled out, so isn't deleted until older than the age
+	client := fake.NewSimpleDynamicClient(defaultScheme, pod,
+		service("web", old, map[string]interface{}{"selector": map[string]interface{}{"app": "web"}}),
+		service("dead", old, map[string]interface{}{"selector": map[string]interface{}{"app": "deleted"}}),
+		service("rolling-out", time.Now(), map[string]interface{}{"selector": map[string]interface{}{"app": "rolling-out"}}),
+		service("external", old, map[string]interface{}{"type": "ExternalName", "externalName": "db.example.com"}),
+		service("manual", old, map[string]interface{}{}),
+	)
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"svc"},
+		Namespaces: []string{"default"},
+		Age:        24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "services", Namespace: "default", Name: "web", Age: 48 * time.Hour, Status: domain.InUse, Action: domain.Unchanged, Reason: "in-use by pods/web-x2v9"},
+		{Kind: "services", Namespace: "default", Name: "dead", Age: 48 * time.Hour, Status: domain.NotInUse, Action: domain.Deleted},
+		{Kind: "services", Namespace: "default", Name: "rolling-out", Status: domain.NotInUse, Action: domain.Unchanged, Reason: domain.ReasonAge},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestUnusedServiceAccountsAndBindings(t *testing.T) {
+	pod := newResource("v1", "Pod", "web-x2v9")
+	pod.Object["spec"] = map[string]interface{}{"serviceAccountName": "web"}
+
+	old := time.Now().Add(-48 * time.Hour)
+	binding := func(kind, name, role string, created time.Time) *unstructured.Unstructured {
+		b := newResourceWithTime("rbac.authorization.k8s.io/v1", kind, name, created)
+		if kind == "ClusterRoleBinding" {
+			b.SetNamespace("")
+		}
+		b.Object["roleRef"] = map[string]interface{}{"kind": "ClusterRole", "name": role}
+		b.Object["subjects"] = []interface{}{map[string]interface{}{"kind": "ServiceAccount", "name": "web", "namespace": "default"}}
+		return b
+	}
+	view := newResource("rbac.authorization.k8s.io/v1", "ClusterRole", "view")
+	view.SetNamespace("")
+
+	// new service accounts and bindings are often created ahead of what uses them, so are kept until older than the age
+	client := fake.NewSimpleDynamicClient(defaultScheme, pod, view,
+		newResourceWithTime("v1", "ServiceAccount", "default", old),
+		newResourceWithTime("v1", "ServiceAccount", "web", old),
+		newResourceWithTime("v1", "ServiceAccount", "unused", old),
+		newResourceWithTime("v1", "ServiceAccount", "deploying", time.Now()),
+		binding("RoleBinding", "web-view", "view", old),
+		binding("RoleBinding", "web-edit", "edit", old),
+		binding("RoleBinding", "deploying-edit", "edit", time.Now()),
+		binding("ClusterRoleBinding", "web-admin", "admin", old),
+	)
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"sa", "rolebindings", "clusterrolebinding"},
+		Namespaces: []string{"default"},
+		Age:        24 * time.Hour,
+		DryRun:     true,
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "serviceaccounts", Namespace: "default", Name: "web", Age: 48 * time.Hour, Status: domain.InUse, Action: domain.Unchanged, Reason: "in-use by pods/web-x2v9"},
+		{Kind: "serviceaccounts", Namespace: "default", Name: "unused", Age: 48 * time.Hour, Status: domain.NotInUse, Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+		{Kind: "serviceaccounts", Namespace: "default", Name: "deploying", Status: domain.NotInUse, Action: domain.Unchanged, Reason: domain.ReasonAge},
+		{Kind: "rolebindings", Namespace: "default", Name: "web-edit", Age: 48 * time.Hour, Status: "MISSING clusterroles/edit", Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+		{Kind: "rolebindings", Namespace: "default", Name: "deploying-edit", Status: "MISSING clusterroles/edit", Action: domain.Unchanged, Reason: domain.ReasonAge},
+		{Kind: "clusterrolebindings", Name: "web-admin", Age: 48 * time.Hour, Status: "MISSING clusterroles/admin", Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestUnusedIngressesAndAutoscalers(t *testing.T) {
+	ingress := func(name, svc string) *unstructured.Unstructured {
+		i := newResource("networking.k8s.io/v1", "Ingress", name)
+		i.Object["spec"] = map[string]interface{}{
+			"defaultBackend": map[string]interface{}{"service": map[string]interface{}{"name": svc}},
+		}
+		return i
+	}
+	hpa := func(name, target string) *unstructured.Unstructured {
+		h := newResource("autoscaling/v1", "HorizontalPodAutoscaler", name)
+		h.Object["spec"] = map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": target},
+		}
+		return h
+	}
+
+	client := fake.NewSimpleDynamicClient(defaultScheme,
+		newResource("v1", "Service", "web"),
+		newResource("apps/v1", "Deployment", "web"),
+		ingress("web", "web"),
+		ingress("removed", "removed"),
+		hpa("web", "web"),
+		hpa("removed", "removed"),
+	)
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"ing", "hpa"},
+		Namespaces: []string{"default"},
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "ingresses", Namespace: "default", Name: "removed", Status: "MISSING services/default/removed", Action: domain.Deleted},
+		{Kind: "horizontalpodautoscalers", Namespace: "default", Name: "removed", Status: "MISSING deployments/default/removed", Action: domain.Deleted},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestUnusedIngressesOnOlderClusters(t *testing.T) {
+	mapper := kubernetes.NewRESTMapper(&fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*meta_v1.APIResourceList{
+		{
+			GroupVersion: "networking.k8s.io/v1beta1",
+			APIResources: []meta_v1.APIResource{
+				{Name: "ingresses", SingularName: "ingress", Kind: "Ingress", Namespaced: true, ShortNames: []string{"ing"}},
+			},
+		},
+	}}})
+
+	ingress := newResource("networking.k8s.io/v1beta1", "Ingress", "removed")
+	ingress.Object["spec"] = map[string]interface{}{"backend": map[string]interface{}{"serviceName": "removed"}}
+	client := fake.NewSimpleDynamicClient(defaultScheme, ingress)
+
+	results, err := Unused(client, mapper, domain.Unused{Resources: []string{"ing"}, Namespaces: []string{"default"}})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "ingresses", Namespace: "default", Name: "removed", Status: "MISSING services/default/removed", Action: domain.Deleted},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	if _, err := client.Resource(kubernetes.IngressV1Beta1Schema).Namespace("default").Get(context.TODO(), "removed", meta_v1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the v1beta1 ingress to be deleted, got: %v", err)
+	}
+}
+
+func TestUnusedReplicaSetsKeepsRevisions(t *testing.T) {
+	controller := true
+	replicaSet := func(name, revision string) *unstructured.Unstructured {
+		rs := newResource("apps/v1", "ReplicaSet", name)
+		rs.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": revision})
+		rs.SetOwnerReferences([]meta_v1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid", Controller: &controller}})
+		rs.Object["spec"] = map[string]interface{}{"replicas": int64(0)}
+		return rs
+	}
+
+	web := newResource("apps/v1", "Deployment", "web")
+	web.SetUID("web-uid")
+	web.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": "3"})
+
+	client := fake.NewSimpleDynamicClient(defaultScheme, web, replicaSet("web-1", "1"), replicaSet("web-2", "2"))
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:     []string{"rs"},
+		Namespaces:    []string{"default"},
+		KeepRevisions: 1,
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "replicasets", Namespace: "default", Name: "web-1", Status: domain.NotInUse, Action: domain.Deleted, Details: "deployment=web revision=1 current=3"},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestUnusedPods(t *testing.T) {
+	pod := func(name string, status map[string]interface{}, t time.Time) *unstructured.Unstructured {
+		p := newResource("v1", "Pod", name)
+		p.SetCreationTimestamp(meta_v1.NewTime(t))
+		p.Object["status"] = status
+		return p
+	}
+
+	client := fake.NewSimpleDynamicClient(defaultScheme,
+		pod("web", map[string]interface{}{"phase": "Running"}, time.Now().Add(-48*time.Hour)),
+		pod("evicted", map[string]interface{}{"phase": "Failed", "reason": "Evicted"}, time.Now().Add(-48*time.Hour)),
+		pod("recent", map[string]interface{}{"phase": "Succeeded"}, time.Now().Add(-1*time.Hour)),
+	)
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:  []string{"po"},
+		Namespaces: []string{"default"},
+		Age:        24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "pods", Namespace: "default", Name: "evicted", Age: 48 * time.Hour, Status: "Evicted", Action: domain.Deleted},
+		{Kind: "pods", Namespace: "default", Name: "recent", Age: time.Hour, Status: "Succeeded", Action: domain.Unchanged, Reason: domain.ReasonAge},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestUnusedJobsKeepsCronJobHistory(t *testing.T) {
+	controller := true
+	cronJobJob := func(job *unstructured.Unstructured) *unstructured.Unstructured {
+		job.SetOwnerReferences([]meta_v1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "nightly", Controller: &controller}})
+		return job
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+	failed := map[string]interface{}{"failed": int64(1), "conditions": []interface{}{newJobCondition("Failed", time.Now().Add(-time.Hour))}}
+
+	nightly := newResource("batch/v1", "CronJob", "nightly")
+	nightly.Object["spec"] = map[string]interface{}{"schedule": "0 0 * * *", "suspend": true}
+
+	client := fake.NewSimpleDynamicClient(defaultScheme, nightly,
+		cronJobJob(newCompletedJobWithTime("nightly-1", time.Now().Add(-48*time.Hour))),
+		cronJobJob(newCompletedJobWithTime("nightly-2", yesterday)),
+		cronJobJob(newJobWithStatusAndTime("nightly-3", failed, time.Now().Add(-time.Hour))),
+	)
+
+	results, err := Unused(client, defaultMapper, domain.Unused{
+		Resources:      []string{"job", "cj"},
+		Namespaces:     []string{"default"},
+		DryRun:         true,
+		KeepSuccessful: 1,
+		KeepFailed:     domain.CronJobHistoryLimit,
+	})
+	if err != nil {
+		t.Fatalf("Unused() unexpected error: %s", err)
+	}
+
+	expected := []domain.Result{
+		{Kind: "jobs", Namespace: "default", Name: "nightly-1", Age: 48 * time.Hour, Status: "Completed", Action: domain.Unchanged, Reason: domain.ReasonDryRun},
+		{Kind: "jobs", Namespace: "default", Name: "nightly-2", Age: 24 * time.Hour, Status: "Completed", Action: domain.Unchanged, Reason: "history of cronjobs/nightly"},
+		{Kind: "jobs", Namespace: "default", Name: "nightly-3", Age: time.Hour, Status: "Failed", Action: domain.Unchanged, Reason: "history of cronjobs/nightly"},
+		{Kind: "cronjobs", Namespace: "default", Name: "nightly", Status: "Suspended", Action: domain.Unchanged, Reason: domain.ReasonDryRun, Details: "lastSuccessfulTime=" + yesterday.UTC().Format(time.RFC3339)},
+	}
+	if diff := cmp.Diff(results, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
 func newResource(api, kind, name string) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -172,25 +537,28 @@ func newPodWithVolumes(name, config, secret string) *unstructured.Unstructured {
 
 func newCompletedJob(name string) *unstructured.Unstructured {
 	return newJobWithStatus(name, map[string]interface{}{
-		"succeeded": int64(1),
+		"succeeded":  int64(1),
+		"conditions": []interface{}{newJobCondition("Complete", time.Now())},
 	})
 }
 
 func newCompletedJobWithTime(name string, t time.Time) *unstructured.Unstructured {
 	return newJobWithStatusAndTime(name, map[string]interface{}{
-		"succeeded": int64(1),
+		"succeeded":  int64(1),
+		"conditions": []interface{}{newJobCondition("Complete", t)},
 	}, t)
 }
 
 func newRunningJob(name string) *unstructured.Unstructured {
 	return newJobWithStatus(name, map[string]interface{}{
-		"running": int64(1),
+		"active": int64(1),
 	})
 }
 
 func newFailedJob(name string) *unstructured.Unstructured {
 	return newJobWithStatus(name, map[string]interface{}{
-		"failed": int64(1),
+		"failed":     int64(1),
+		"conditions": []interface{}{newJobCondition("Failed", time.Now())},
 	})
 }
 
@@ -237,3 +605,7 @@ func newJobWithStatusAndTime(name string, status map[string]interface{}, t time.
 		},
 	}
 }
+
+func newJobCondition(t string, transitioned time.Time) map[string]interface{} {
+	return map[string]interface{}{"type": t, "status": "True", "lastTransitionTime": transitioned.Format(time.RFC3339)}
+}
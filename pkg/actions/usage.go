@@ -0,0 +1,95 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
+)
+
+// Usage reports the Deployments and StatefulSets using more than 'u.LimitThreshold' percent of their CPU or memory limits,
+// or less than 'u.RequestThreshold' percent of their requests, from the pod metrics of the 'metrics.k8s.io' API.
+// Workloads are only reported, so the action is always unchanged.
+func Usage(c dynamic.Interface, m meta.RESTMapper, u domain.Usage) ([]domain.Result, error) {
+	var results []domain.Result
+	for _, resource := range u.Resources {
+		mapping, err := kubernetes.ResolveResource(m, resource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unsupported resource: %s", resource)
+		}
+
+		gvr := mapping.Resource
+		if gvr.GroupResource() != kubernetes.DeploymentSchema.GroupResource() && gvr.GroupResource() != kubernetes.StatefulSetSchema.GroupResource() {
+			return nil, errors.Errorf("unsupported resource: %s", resource)
+		}
+
+		for _, namespace := range u.Namespaces {
+			workloads, err := kubernetes.WorkloadUsages(c, gvr, namespace, u.Allow)
+			if err != nil {
+				return results, errors.Wrapf(err, "executing for resource type (%s)", resource)
+			}
+
+			for _, workload := range workloads {
+				var statuses, reasons []string
+				for _, r := range []struct {
+					name  string
+					usage kubernetes.ResourceUsage
+				}{{"cpu", workload.CPU}, {"memory", workload.Memory}} {
+					if p, limited := r.usage.PercentOfLimits(); limited && p >= u.LimitThreshold {
+						statuses = appendMissing(statuses, domain.OverLimit)
+						reasons = append(reasons, fmt.Sprintf("%s at %.0f%% of limits", r.name, p))
+					}
+					if p, requested := r.usage.PercentOfRequests(); requested && p < u.RequestThreshold {
+						statuses = appendMissing(statuses, domain.OverProvisioned)
+						reasons = append(reasons, fmt.Sprintf("%s at %.0f%% of requests", r.name, p))
+					}
+				}
+
+				if len(statuses) == 0 {
+					continue
+				}
+
+				results = append(results, domain.Result{
+					Kind:      gvr.Resource,
+					Namespace: namespace,
+					Name:      workload.Name,
+					Age:       workload.Age,
+					Status:    strings.Join(statuses, ","),
+					Reason:    strings.Join(reasons, ", "),
+					Action:    domain.Unchanged,
+					Details:   usageDetails(workload),
+				})
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// usageDetails describes the usage of a workload, i.e. "pods=2 cpu=950m/500m/1 memory=200Mi/256Mi/-" (usage/requests/limits).
+func usageDetails(w kubernetes.WorkloadUsage) string {
+	format := func(u kubernetes.ResourceUsage) string {
+		quantities := []string{u.Usage.String(), "-", "-"}
+		if !u.Requests.IsZero() {
+			quantities[1] = u.Requests.String()
+		}
+		if !u.Limits.IsZero() {
+			quantities[2] = u.Limits.String()
+		}
+		return strings.Join(quantities, "/")
+	}
+	return fmt.Sprintf("pods=%d cpu=%s memory=%s", w.Pods, format(w.CPU), format(w.Memory))
+}
+
+func appendMissing(l []string, s string) []string {
+	for _, e := range l {
+		if e == s {
+			return l
+		}
+	}
+	return append(l, s)
+}
@@ -0,0 +1,98 @@
+package actions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/ahstn/karetaker/pkg/kubernetes"
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestUsage(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	workload := func(name string) *unstructured.Unstructured {
+		w := newResource("apps/v1", "Deployment", name)
+		w.SetCreationTimestamp(meta_v1.NewTime(created))
+		w.Object["spec"] = map[string]interface{}{
+			"replicas": int64(1),
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+		}
+		return w
+	}
+	pod := func(name string, resources map[string]interface{}) *unstructured.Unstructured {
+		p := newResource("v1", "Pod", name+"-a")
+		p.SetLabels(map[string]string{"app": name})
+		p.Object["spec"] = map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app", "image": "app", "resources": resources}},
+		}
+		return p
+	}
+	metrics := func(name, cpu, memory string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "metrics.k8s.io/v1beta1",
+			"kind":       "PodMetrics",
+			"metadata":   map[string]interface{}{"name": name + "-a", "namespace": "default"},
+			"containers": []interface{}{map[string]interface{}{"name": "app", "usage": map[string]interface{}{"cpu": cpu, "memory": memory}}},
+		}}
+	}
+	resources := map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "500m", "memory": "256Mi"},
+		"limits":   map[string]interface{}{"cpu": "1", "memory": "512Mi"},
+	}
+
+	tests := []struct {
+		name     string
+		config   domain.Usage
+		expected []domain.Result
+		wantErr  bool
+	}{
+		{
+			name:   "Workloads near their limits or far below their requests are reported",
+			config: domain.Usage{Resources: []string{"deploy"}, Namespaces: []string{"default"}, LimitThreshold: 90, RequestThreshold: 10},
+			expected: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "hot", Age: time.Hour, Status: domain.OverLimit, Reason: "cpu at 95% of limits", Action: domain.Unchanged, Details: "pods=1 cpu=950m/500m/1 memory=200Mi/256Mi/512Mi"},
+				{Kind: "deployments", Namespace: "default", Name: "idle", Age: time.Hour, Status: domain.OverProvisioned, Reason: "cpu at 2% of requests, memory at 8% of requests", Action: domain.Unchanged, Details: "pods=1 cpu=10m/500m/1 memory=20Mi/256Mi/512Mi"},
+			},
+		},
+		{
+			name:   "Workloads matching the allow list are ignored",
+			config: domain.Usage{Resources: []string{"deploy"}, Namespaces: []string{"default"}, Allow: []string{"idle"}, LimitThreshold: 90, RequestThreshold: 10},
+			expected: []domain.Result{
+				{Kind: "deployments", Namespace: "default", Name: "hot", Age: time.Hour, Status: domain.OverLimit, Reason: "cpu at 95% of limits", Action: domain.Unchanged, Details: "pods=1 cpu=950m/500m/1 memory=200Mi/256Mi/512Mi"},
+			},
+		},
+		{
+			name:    "Error is returned on unsupported resource types",
+			config:  domain.Usage{Resources: []string{"configmap"}, Namespaces: []string{"default"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(defaultScheme,
+				workload("hot"), workload("idle"), workload("steady"),
+				pod("hot", resources), pod("idle", resources), pod("steady", resources),
+			)
+			for _, m := range []*unstructured.Unstructured{metrics("hot", "950m", "200Mi"), metrics("idle", "10m", "20Mi"), metrics("steady", "300m", "200Mi")} {
+				if _, err := client.Resource(kubernetes.PodMetricsSchema).Namespace("default").Create(context.TODO(), m, meta_v1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			actual, err := Usage(client, defaultMapper, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Usage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if diff := cmp.Diff(actual, tt.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
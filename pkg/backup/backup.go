@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// coreGroup is used in paths for the core API group, which has an empty name
+	coreGroup = "core"
+
+	// clusterScope is used in paths for cluster-scoped objects, which have no namespace
+	clusterScope = "_cluster"
+)
+
+// Object is a backed up object alongside the resource type it was retrieved from.
+type Object struct {
+	Resource schema.GroupVersionResource
+	Object   *unstructured.Unstructured
+}
+
+// Dir returns a timestamped backup directory within 'root' for the time 't'.
+func Dir(root string, t time.Time) string {
+	return filepath.Join(root, t.UTC().Format("20060102T150405Z"))
+}
+
+// Save writes the object 'obj' of resource type 'r' to the backup directory 'd' as YAML.
+// Server populated fields (status, uid, resourceVersion, managedFields, etc) are stripped, so it can be re-created.
+// Objects are stored as '<namespace>/<group>/<version>/<resource>/<name>.yaml' so they can be restored without discovery.
+func Save(d string, r schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	obj = Strip(obj)
+
+	path := objectPath(d, r, obj.GetNamespace(), obj.GetName())
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "creating backup directory")
+	}
+
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return errors.Wrap(err, "marshalling backup")
+	}
+
+	return errors.Wrap(ioutil.WriteFile(path, data, 0600), "writing backup")
+}
+
+// jobLabels are set on the pod template of Jobs by the API server, to select their pods by the Job's UID
+var jobLabels = []string{"controller-uid", "batch.kubernetes.io/controller-uid", "job-name", "batch.kubernetes.io/job-name"}
+
+// Strip returns a copy of 'obj' without the fields populated by the API server.
+// Owner references are also removed, as their owners' UIDs no longer exist and the restored object would be
+// garbage collected. Jobs lose their generated selector, which the API server rejects unless 'manualSelector' is set.
+func Strip(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range []string{"uid", "resourceVersion", "managedFields", "selfLink", "creationTimestamp", "generation", "ownerReferences"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+
+	manual, _, _ := unstructured.NestedBool(obj.Object, "spec", "manualSelector")
+	if obj.GetKind() == "Job" && strings.HasPrefix(obj.GetAPIVersion(), "batch/") && !manual {
+		unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+		for _, label := range jobLabels {
+			unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", label)
+		}
+	}
+	return obj
+}
+
+// Load reads all the objects from the backup directory 'd'.
+func Load(d string) ([]Object, error) {
+	var objects []Object
+	err := filepath.Walk(d, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".yaml" {
+			return err
+		}
+
+		rel, err := filepath.Rel(d, path)
+		if err != nil {
+			return err
+		}
+
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 5 {
+			return errors.Errorf("unexpected backup path: %s", rel)
+		}
+
+		group := parts[1]
+		if group == coreGroup {
+			group = ""
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(data, &obj.Object); err != nil {
+			return errors.Wrapf(err, "parsing backup %s", rel)
+		}
+
+		objects = append(objects, Object{
+			Resource: schema.GroupVersionResource{Group: group, Version: parts[2], Resource: parts[3]},
+			Object:   obj,
+		})
+		return nil
+	})
+
+	return objects, errors.Wrap(err, "reading backups")
+}
+
+func objectPath(d string, r schema.GroupVersionResource, namespace, name string) string {
+	group := r.Group
+	if group == "" {
+		group = coreGroup
+	}
+	if namespace == "" {
+		namespace = clusterScope
+	}
+
+	return filepath.Join(d, namespace, group, r.Version, r.Resource, name+".yaml")
+}
@@ -0,0 +1,156 @@
+package backup
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	configResource = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	deployResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+func TestDir(t *testing.T) {
+	got := Dir("backups", time.Date(2021, 4, 20, 13, 5, 0, 0, time.UTC))
+	if want := filepath.Join("backups", "20210420T130500Z"); got != want {
+		t.Errorf("Dir() got = %v, want %v", got, want)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := Save(dir, configResource, newServerObject("v1", "ConfigMap", "default", "properties")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(dir, deployResource, newServerObject("apps/v1", "Deployment", "team-a", "auth")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "default", "core", "v1", "configmaps", "properties.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v1
+data:
+  key: value
+kind: ConfigMap
+metadata:
+  labels:
+    app: auth
+  name: properties
+  namespace: default
+`
+	if diff := cmp.Diff(string(data), want); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", want, diff)
+	}
+
+	objects, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Object{
+		{Resource: configResource, Object: Strip(newServerObject("v1", "ConfigMap", "default", "properties"))},
+		{Resource: deployResource, Object: Strip(newServerObject("apps/v1", "Deployment", "team-a", "auth"))},
+	}
+	if diff := cmp.Diff(objects, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestLoadRejectsUnexpectedPaths(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "properties.yaml"), []byte("kind: ConfigMap"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(dir); err == nil {
+		t.Errorf("Expected error, but got: %s", err)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	owner := []interface{}{map[string]interface{}{"apiVersion": "batch/v1", "kind": "CronJob", "name": "nightly", "uid": "8f2c"}}
+	selector := map[string]interface{}{"matchLabels": map[string]interface{}{"controller-uid": "8f2d"}}
+	template := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{
+			"app": "nightly", "controller-uid": "8f2d", "batch.kubernetes.io/controller-uid": "8f2d", "job-name": "nightly-1", "batch.kubernetes.io/job-name": "nightly-1",
+		}},
+	}
+
+	job := newServerObject("batch/v1", "Job", "default", "nightly-1")
+	job.Object["metadata"].(map[string]interface{})["ownerReferences"] = owner
+	job.Object["spec"] = map[string]interface{}{"selector": selector, "template": template}
+
+	manual := newServerObject("batch/v1", "Job", "default", "manual")
+	manual.Object["spec"] = map[string]interface{}{"manualSelector": true, "selector": selector}
+
+	pod := newServerObject("v1", "Pod", "default", "nightly-1-abcde")
+	pod.Object["metadata"].(map[string]interface{})["ownerReferences"] = owner
+
+	tests := []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		expected map[string]interface{}
+	}{
+		{
+			name: "Jobs lose their owner, generated selector and template labels",
+			obj:  job,
+			expected: map[string]interface{}{
+				"template": map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "nightly"}}},
+			},
+		},
+		{
+			name:     "Jobs with a manual selector keep it",
+			obj:      manual,
+			expected: map[string]interface{}{"manualSelector": true, "selector": selector},
+		},
+		{
+			name: "Owned objects lose their owner",
+			obj:  pod,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped := Strip(tt.obj)
+			if refs := stripped.GetOwnerReferences(); len(refs) > 0 {
+				t.Errorf("Strip() expected owner references to be removed, got: %v", refs)
+			}
+
+			spec, _, _ := unstructured.NestedMap(stripped.Object, "spec")
+			if diff := cmp.Diff(spec, tt.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func newServerObject(api, kind, namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": api,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"namespace":         namespace,
+				"name":              name,
+				"uid":               "6c1a8e4e-8b0e-4d1b-a2b5-0d1e6f7f5e2a",
+				"resourceVersion":   "1234",
+				"creationTimestamp": "2021-04-20T13:05:00Z",
+				"labels":            map[string]interface{}{"app": "auth"},
+				"managedFields": []interface{}{
+					map[string]interface{}{"manager": "kubectl"},
+				},
+			},
+			"data":   map[string]interface{}{"key": "value"},
+			"status": map[string]interface{}{"replicas": int64(1)},
+		},
+	}
+}
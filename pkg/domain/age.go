@@ -13,16 +13,37 @@ type Unused struct {
 	// Age is an optional target to filter on
 	Age time.Duration
 
-	// Namespace is the Kubernetes namespace to operate in
-	Namespace string
+	// Namespaces are the Kubernetes namespaces to operate in
+	Namespaces []string
 
 	// Allow is a list of patterns to ignore when operating (i.e. don't delete objects containing these)
 	Allow []string
 
 	// DryRun controls if the deletion occurs or not
 	DryRun bool
+
+	// Backup is the directory objects are saved to before deletion, disabled when empty
+	Backup string
+
+	// KeepRevisions is the number of old ReplicaSet revisions kept per Deployment
+	KeepRevisions int
+
+	// KeepSuccessful is the number of completed Jobs kept per CronJob (see CronJobHistoryLimit)
+	KeepSuccessful int
+
+	// KeepFailed is the number of failed Jobs kept per CronJob (see CronJobHistoryLimit)
+	KeepFailed int
+
+	// SuccessWindow is how long a CronJob can go without a successful Job before it's stale, disabled when zero
+	SuccessWindow time.Duration
 }
 
+// DefaultSuccessWindow is how long a CronJob can go without a successful Job before it's stale, unless configured.
+const DefaultSuccessWindow = 7 * 24 * time.Hour
+
+// CronJobHistoryLimit keeps as many Jobs as the CronJob's own successfulJobsHistoryLimit (or failedJobsHistoryLimit).
+const CronJobHistoryLimit = -1
+
 type Age struct {
 	// Resources are all the types to act on, i.e. ("deployment", "configmap")
 	Resources []string
@@ -30,17 +51,29 @@ type Age struct {
 	// Age is the target to filter on
 	Age time.Duration
 
-	// Namespace is the Kubernetes namespace to operate in
-	Namespace string
+	// Namespaces are the Kubernetes namespaces to operate in
+	Namespaces []string
 
 	// Allow is a list of patterns to ignore when operating (i.e. don't delete objects containing these)
 	Allow []string
 
 	// DryRun controls if the deletion occurs or not
 	DryRun bool
+
+	// Backup is the directory objects are saved to before deletion, disabled when empty
+	Backup string
+
+	// IdleFor only selects Deployments without activity over this window (see Prometheus), disabled when zero
+	IdleFor time.Duration
+
+	// Prometheus is the address of the Prometheus-compatible API activity is queried from (i.e. "http://prometheus:9090")
+	Prometheus string
+
+	// IdleQuery is the template of the activity query, defaulting to prometheus.DefaultActivityQuery when empty
+	IdleQuery string
 }
 
-func NewAgeConfig(r, a, n string, allow []string, d bool) (Age, error) {
+func NewAgeConfig(r, a string, n, allow []string, d bool) (Age, error) {
 	age, err := time.ParseDuration(a)
 	if err != nil {
 		return Age{}, errors.Wrap(err, "unsupported duration")
@@ -51,11 +84,11 @@ func NewAgeConfig(r, a, n string, allow []string, d bool) (Age, error) {
 		Age:       age,
 		Allow:     allow,
 		DryRun:    d,
-		Namespace: n,
+		Namespaces: n,
 	}, nil
 }
 
-func NewUnusedConfigWithAge(r, a, n string, allow []string, d bool) (Unused, error) {
+func NewUnusedConfigWithAge(r, a string, n, allow []string, d bool) (Unused, error) {
 	var age time.Duration
 	age, err := time.ParseDuration(a)
 	if err != nil {
@@ -67,7 +100,10 @@ func NewUnusedConfigWithAge(r, a, n string, allow []string, d bool) (Unused, err
 		Age:       age,
 		Allow:     allow,
 		DryRun:    d,
-		Namespace: n,
+		Namespaces: n,
+		KeepSuccessful: CronJobHistoryLimit,
+		KeepFailed:     CronJobHistoryLimit,
+		SuccessWindow:  DefaultSuccessWindow,
 	}, nil
 }
 
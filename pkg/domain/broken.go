@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultBrokenAge is how long a workload must be scaled to zero or unavailable for before it's broken, unless configured.
+const DefaultBrokenAge = 24 * time.Hour
+
+// Broken is the configuration for finding unhealthy workloads (see actions.Broken).
+type Broken struct {
+	// Resources are all the types to act on, i.e. ("deployment", "statefulset")
+	Resources []string
+
+	// Age is how long a workload must be scaled to zero or unavailable for before it's broken
+	Age time.Duration
+
+	// Namespaces are the Kubernetes namespaces to operate in
+	Namespaces []string
+
+	// Allow is a list of patterns to ignore when operating (i.e. don't delete objects containing these)
+	Allow []string
+
+	// DryRun controls if the deletion occurs or not
+	DryRun bool
+
+	// Backup is the directory objects are saved to before deletion, disabled when empty
+	Backup string
+}
+
+func NewBrokenConfig(r, a string, n, allow []string, d bool) (Broken, error) {
+	age, err := time.ParseDuration(a)
+	if err != nil {
+		return Broken{}, errors.Wrap(err, "unsupported duration")
+	}
+
+	return Broken{
+		Resources:  strings.Split(r, ","),
+		Age:        age,
+		Allow:      allow,
+		DryRun:     d,
+		Namespaces: n,
+	}, nil
+}
@@ -0,0 +1,238 @@
+package domain
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AgeOperation targets resources older than a specific age (see Age)
+	AgeOperation = "age"
+
+	// UnusedOperation targets resources not in use by another object (see Unused)
+	UnusedOperation = "unused"
+
+	// BrokenOperation targets unhealthy workloads (see Broken)
+	BrokenOperation = "broken"
+)
+
+// Policy is a declarative set of clean-up rules, executed in order as a batch.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+
+	// Backup is the directory objects are saved to before deletion, set from the CLI rather than the file
+	Backup string `json:"-"`
+}
+
+// Rule is a single clean-up operation within a Policy, mirroring the flags of the equivalent command.
+type Rule struct {
+	// Name identifies the rule in the summary, defaulting to its position in the policy
+	Name string `json:"name,omitempty"`
+
+	// Operation is the type of clean-up, either "age", "unused" or "broken"
+	Operation string `json:"operation"`
+
+	// Resources are all the types to act on, i.e. ("deployment", "configmap")
+	Resources []string `json:"resources"`
+
+	// Namespaces are the Kubernetes namespaces to operate in, defaulting to the kubeconfig context's namespace
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// AllNamespaces operates across all namespaces, excluding the system namespaces
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// NamespaceSelector operates across namespaces matching a label selector
+	NamespaceSelector string `json:"namespaceSelector,omitempty"`
+
+	// ExcludeNamespaces are skipped when using AllNamespaces or NamespaceSelector
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// Age is the duration to filter on (i.e. "48h"), required for "age" operations
+	Age string `json:"age,omitempty"`
+
+	// Allow is a list of patterns to ignore when operating (i.e. don't delete objects containing these)
+	Allow []string `json:"allow,omitempty"`
+
+	// DryRun controls if the deletion occurs or not, defaulting to true
+	DryRun *bool `json:"dryRun,omitempty"`
+
+	// KeepRevisions is the number of old ReplicaSet revisions kept per Deployment by "unused" operations
+	KeepRevisions int `json:"keepRevisions,omitempty"`
+
+	// KeepSuccessful is the number of completed Jobs kept per CronJob, defaulting to the CronJob's own history limit
+	KeepSuccessful *int `json:"keepSuccessful,omitempty"`
+
+	// KeepFailed is the number of failed Jobs kept per CronJob, defaulting to the CronJob's own history limit
+	KeepFailed *int `json:"keepFailed,omitempty"`
+
+	// SuccessWindow is how long a CronJob can go without a successful Job before it's stale, defaulting to "168h"
+	// like the CLI, and disabled with "0s"
+	SuccessWindow string `json:"successWindow,omitempty"`
+
+	// IdleFor only selects Deployments without activity over this window (i.e. "72h") for "age" operations
+	IdleFor string `json:"idleFor,omitempty"`
+
+	// Prometheus is the address of the Prometheus-compatible API activity is queried from, required with IdleFor
+	Prometheus string `json:"prometheus,omitempty"`
+
+	// IdleQuery is the template of the activity query, defaulting to the requests reported by Istio
+	IdleQuery string `json:"idleQuery,omitempty"`
+}
+
+// LoadPolicy reads and validates the Policy file at path 'p'.
+func LoadPolicy(p string) (Policy, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return Policy{}, errors.Wrap(err, "reading policy")
+	}
+
+	return ParsePolicy(data)
+}
+
+// ParsePolicy parses and validates a YAML (or JSON) Policy, rejecting unknown fields.
+func ParsePolicy(data []byte) (Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return Policy{}, errors.Wrap(err, "parsing policy")
+	}
+
+	for i := range p.Rules {
+		if p.Rules[i].Name == "" {
+			p.Rules[i].Name = fmt.Sprintf("rule-%d", i+1)
+		}
+	}
+
+	return p, p.Validate()
+}
+
+// Validate checks every rule up front, so a policy is never partially executed due to a typo.
+func (p Policy) Validate() error {
+	if len(p.Rules) == 0 {
+		return errors.New("policy has no rules")
+	}
+
+	var problems []string
+	for _, r := range p.Rules {
+		if err := r.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", r.Name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid policy:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Validate checks the operation, resources, age and namespace selection of a rule.
+func (r Rule) Validate() error {
+	switch r.Operation {
+	case AgeOperation:
+		if r.Age == "" {
+			return errors.New("age is required for age operations")
+		}
+	case UnusedOperation, BrokenOperation:
+	default:
+		return errors.Errorf("unsupported operation %q", r.Operation)
+	}
+
+	if len(r.Resources) == 0 {
+		return errors.New("at least one resource is required")
+	}
+
+	if r.Age != "" {
+		if _, err := time.ParseDuration(r.Age); err != nil {
+			return errors.Wrap(err, "unsupported duration")
+		}
+	}
+
+	if r.KeepRevisions < 0 {
+		return errors.New("keepRevisions can't be negative")
+	}
+
+	if (r.KeepSuccessful != nil && *r.KeepSuccessful < 0) || (r.KeepFailed != nil && *r.KeepFailed < 0) {
+		return errors.New("keepSuccessful and keepFailed can't be negative")
+	}
+
+	if r.SuccessWindow != "" {
+		if _, err := time.ParseDuration(r.SuccessWindow); err != nil {
+			return errors.Wrap(err, "unsupported successWindow")
+		}
+	}
+
+	if r.IdleFor != "" {
+		if _, err := time.ParseDuration(r.IdleFor); err != nil {
+			return errors.Wrap(err, "unsupported idleFor")
+		}
+		if r.Prometheus == "" {
+			return errors.New("prometheus is required with idleFor")
+		}
+	}
+
+	if len(r.Namespaces) > 0 && (r.AllNamespaces || r.NamespaceSelector != "") {
+		return errors.New("namespaces can't be combined with allNamespaces or namespaceSelector")
+	}
+
+	return nil
+}
+
+// IsDryRun returns if the rule should only show resources, which is the default when unset.
+func (r Rule) IsDryRun() bool {
+	return r.DryRun == nil || *r.DryRun
+}
+
+// AgeConfig converts the rule into the Age configuration for namespaces 'n'.
+func (r Rule) AgeConfig(n []string) (Age, error) {
+	a, err := NewAgeConfig(strings.Join(r.Resources, ","), r.Age, n, r.Allow, r.IsDryRun())
+	if err != nil {
+		return Age{}, err
+	}
+
+	if r.IdleFor != "" {
+		a.IdleFor, err = time.ParseDuration(r.IdleFor)
+		if err != nil {
+			return Age{}, errors.Wrap(err, "unsupported idleFor")
+		}
+	}
+	a.Prometheus = r.Prometheus
+	a.IdleQuery = r.IdleQuery
+	return a, nil
+}
+
+// BrokenConfig converts the rule into the Broken configuration for namespaces 'n'.
+// Without an age, it defaults to DefaultBrokenAge like the "broken" command.
+func (r Rule) BrokenConfig(n []string) (Broken, error) {
+	age := r.Age
+	if age == "" {
+		age = DefaultBrokenAge.String()
+	}
+	return NewBrokenConfig(strings.Join(r.Resources, ","), age, n, r.Allow, r.IsDryRun())
+}
+
+// UnusedConfig converts the rule into the Unused configuration for namespaces 'n'.
+func (r Rule) UnusedConfig(n []string) (Unused, error) {
+	u, err := NewUnusedConfigWithAge(strings.Join(r.Resources, ","), r.Age, n, r.Allow, r.IsDryRun())
+	if err != nil {
+		return Unused{}, err
+	}
+
+	u.KeepRevisions = r.KeepRevisions
+	if r.KeepSuccessful != nil {
+		u.KeepSuccessful = *r.KeepSuccessful
+	}
+	if r.KeepFailed != nil {
+		u.KeepFailed = *r.KeepFailed
+	}
+	if r.SuccessWindow != "" {
+		u.SuccessWindow, err = time.ParseDuration(r.SuccessWindow)
+		if err != nil {
+			return Unused{}, errors.Wrap(err, "unsupported successWindow")
+		}
+	}
+	return u, nil
+}
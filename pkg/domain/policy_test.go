@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		want    []Rule
+		wantErr string
+	}{
+		{
+			name: "Parses rules and defaults their names",
+			policy: `
+rules:
+- operation: age
+  resources: [deploy, svc]
+  namespaces: [feature-a]
+  age: 72h
+- name: unused-configs
+  operation: unused
+  resources: [configmap]
+  namespaceSelector: env=dev
+  dryRun: false
+`,
+			want: []Rule{
+				{Name: "rule-1", Operation: "age", Resources: []string{"deploy", "svc"}, Namespaces: []string{"feature-a"}, Age: "72h"},
+				{Name: "unused-configs", Operation: "unused", Resources: []string{"configmap"}, NamespaceSelector: "env=dev", DryRun: new(bool)},
+			},
+		},
+		{
+			name:    "Rejects unknown fields",
+			policy:  "rules:\n- operation: age\n  resources: [deploy]\n  age: 1h\n  dry-run: false\n",
+			wantErr: "unknown field",
+		},
+		{
+			name:    "Rejects a policy without rules",
+			policy:  "rules: []\n",
+			wantErr: "policy has no rules",
+		},
+		{
+			name: "Reports every invalid rule",
+			policy: `
+rules:
+- operation: delete
+  resources: [deploy]
+- operation: age
+  resources: [deploy]
+- operation: unused
+  resources: [configmap]
+  age: 2 days
+- operation: unused
+- operation: unused
+  resources: [job]
+  namespaces: [default]
+  allNamespaces: true
+- operation: unused
+  resources: [replicaset]
+  keepRevisions: -1
+- operation: unused
+  resources: [cronjob]
+  successWindow: a week
+- operation: age
+  resources: [deploy]
+  age: 72h
+  idleFor: 72h
+`,
+			wantErr: `invalid policy:
+  rule-1: unsupported operation "delete"
+  rule-2: age is required for age operations
+  rule-3: unsupported duration: time: unknown unit " days" in duration "2 days"
+  rule-4: at least one resource is required
+  rule-5: namespaces can't be combined with allNamespaces or namespaceSelector
+  rule-6: keepRevisions can't be negative
+  rule-7: unsupported successWindow: time: invalid duration "a week"
+  rule-8: prometheus is required with idleFor`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy([]byte(tt.policy))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParsePolicy() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			} else if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+
+			if diff := cmp.Diff(got.Rules, tt.want); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.want, diff)
+			}
+		})
+	}
+}
+
+func TestRuleConfig(t *testing.T) {
+	r := Rule{Operation: AgeOperation, Resources: []string{"deploy", "svc"}, Age: "72h", Allow: []string{"istio"}}
+
+	got, err := r.AgeConfig([]string{"team-a"})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	want := Age{
+		Resources:  []string{"deploy", "svc"},
+		Age:        72 * time.Hour,
+		Namespaces: []string{"team-a"},
+		Allow:      []string{"istio"},
+		DryRun:     true,
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", want, diff)
+	}
+}
+
+func TestRuleIdleConfig(t *testing.T) {
+	r := Rule{Operation: AgeOperation, Resources: []string{"deploy"}, Age: "24h", IdleFor: "72h", Prometheus: "http://prometheus:9090"}
+
+	got, err := r.AgeConfig([]string{"team-a"})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	want := Age{
+		Resources:  []string{"deploy"},
+		Age:        24 * time.Hour,
+		Namespaces: []string{"team-a"},
+		DryRun:     true,
+		IdleFor:    72 * time.Hour,
+		Prometheus: "http://prometheus:9090",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", want, diff)
+	}
+}
+
+func TestRuleUnusedConfigDefaultsSuccessWindow(t *testing.T) {
+	for window, expected := range map[string]time.Duration{"": DefaultSuccessWindow, "0s": 0, "24h": 24 * time.Hour} {
+		r := Rule{Operation: UnusedOperation, Resources: []string{"cronjob"}, SuccessWindow: window}
+
+		got, err := r.UnusedConfig([]string{"team-a"})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+
+		if got.SuccessWindow != expected {
+			t.Errorf("successWindow %q got = %s, want %s", window, got.SuccessWindow, expected)
+		}
+	}
+}
+
+func TestRuleBrokenConfig(t *testing.T) {
+	r := Rule{Operation: BrokenOperation, Resources: []string{"deploy"}}
+
+	got, err := r.BrokenConfig([]string{"team-a"})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	want := Broken{Resources: []string{"deploy"}, Age: DefaultBrokenAge, Namespaces: []string{"team-a"}, DryRun: true}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", want, diff)
+	}
+}
+
+func TestRuleUnusedConfig(t *testing.T) {
+	keepFailed := 0
+	r := Rule{Operation: UnusedOperation, Resources: []string{"replicaset", "job"}, KeepRevisions: 2, KeepFailed: &keepFailed, SuccessWindow: "168h"}
+
+	got, err := r.UnusedConfig([]string{"team-a"})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	want := Unused{
+		Resources:      []string{"replicaset", "job"},
+		Namespaces:     []string{"team-a"},
+		DryRun:         true,
+		KeepRevisions:  2,
+		KeepSuccessful: CronJobHistoryLimit,
+		KeepFailed:     0,
+		SuccessWindow:  168 * time.Hour,
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", want, diff)
+	}
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "strings"
+
+type Restore struct {
+	// Backup is the backup directory to restore objects from
+	Backup string
+
+	// Objects are the objects to restore as "type" or "type/name" (i.e. "configmap/properties"), all when empty
+	Objects []string
+
+	// Namespace limits the restored objects to a single namespace, all when empty
+	Namespace string
+
+	// DryRun controls if the objects are re-created or not
+	DryRun bool
+}
+
+func NewRestoreConfig(b, o, n string, d bool) Restore {
+	var objects []string
+	if o != "" {
+		objects = strings.Split(o, ",")
+	}
+
+	return Restore{
+		Backup:    b,
+		Objects:   objects,
+		Namespace: n,
+		DryRun:    d,
+	}
+}
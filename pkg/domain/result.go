@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Action is what was done to an object by an operation.
+type Action string
+
+const (
+	Deleted       Action = "DELETED"
+	Unchanged     Action = "UN-CHANGED"
+	DeleteFailed  Action = "DELETE-FAILED"
+	Restored      Action = "RESTORED"
+	RestoreFailed Action = "RESTORE-FAILED"
+)
+
+const (
+	// Expired objects are older than the targeted age
+	Expired = "EXPIRED"
+
+	// InUse objects are referenced by another object
+	InUse = "IN-USE"
+
+	// NotInUse objects aren't referenced by any other object
+	NotInUse = "UN-USED"
+
+	// Missing objects reference other objects which no longer exist, and are followed by their names
+	Missing = "MISSING"
+
+	// Duplicated objects are similar to other objects
+	Duplicated = "DUPLICATE"
+
+	// Protected objects are exempt from clean-up by an annotation or label on themselves or their namespace
+	Protected = "PROTECTED"
+
+	// BackedUp objects were saved to a backup before deletion
+	BackedUp = "BACKED-UP"
+)
+
+// Reasons for leaving an object unchanged.
+const (
+	ReasonDryRun  = "dry-run"
+	ReasonAge     = "age"
+	ReasonInUse   = "in-use"
+	ReasonExists  = "already exists"
+	ReasonHistory = "history"
+)
+
+// Result is the outcome of an operation for a single object, rendered by the CLI in the chosen format.
+type Result struct {
+	// Kind is the resource type of the object, i.e. "deployments"
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the object
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the object
+	Name string `json:"name"`
+
+	// Age is the time since the object was created (zero when not known)
+	Age time.Duration `json:"-"`
+
+	// Expires is when the object expired, from its TTL annotations or the targeted age (zero when not applicable)
+	Expires time.Time `json:"-"`
+
+	// Status is the state of the object that caused it to be selected (i.e. "UN-USED" or "Failed")
+	Status string `json:"status"`
+
+	// Reason explains the action, i.e. why an object was left unchanged or failed to delete
+	Reason string `json:"reason,omitempty"`
+
+	// Action is what was done to the object
+	Action Action `json:"action"`
+
+	// Details are additional information about the object (i.e. a volume's capacity and storage class)
+	Details string `json:"details,omitempty"`
+}
+
+// MarshalJSON encodes the age as a human readable duration (i.e. "72h0m0s") rather than nanoseconds,
+// and omits the expiry when it isn't set.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		Age     string `json:"age,omitempty"`
+		Expires string `json:"expires,omitempty"`
+	}{result(r), formatAge(r.Age), FormatExpiry(r.Expires)})
+}
+
+// FormatExpiry formats the expiry as an RFC3339 timestamp in UTC, or empty when it isn't set.
+func FormatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatAge(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// RuleResult is the outcome of a single Rule within a Policy.
+type RuleResult struct {
+	// Rule is the name of the rule
+	Rule string `json:"rule"`
+
+	// Operation is the type of clean-up the rule performed
+	Operation string `json:"operation"`
+
+	// Namespaces are the namespaces the rule operated in
+	Namespaces []string `json:"namespaces"`
+
+	// Results are the objects the rule operated on
+	Results []Result `json:"results"`
+
+	// Error is why the rule failed, if it did
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"strings"
+)
+
+const (
+	// OverLimit workloads are using more than the targeted percentage of their limits
+	OverLimit = "OVER-LIMIT"
+
+	// OverProvisioned workloads are using less than the targeted percentage of their requests
+	OverProvisioned = "OVER-PROVISIONED"
+)
+
+// Usage is the configuration for reporting workloads by their resource usage (see actions.Usage).
+type Usage struct {
+	// Resources are all the types to report on, i.e. ("deployment", "statefulset")
+	Resources []string
+
+	// Namespaces are the Kubernetes namespaces to operate in
+	Namespaces []string
+
+	// Allow is a list of patterns to ignore when operating (i.e. don't report objects containing these)
+	Allow []string
+
+	// LimitThreshold is the percentage of their limits workloads are reported above (i.e. 90)
+	LimitThreshold float64
+
+	// RequestThreshold is the percentage of their requests workloads are reported below (i.e. 10), disabled when zero
+	RequestThreshold float64
+}
+
+func NewUsageConfig(r string, n, allow []string, limit, request float64) Usage {
+	return Usage{
+		Resources:        strings.Split(r, ","),
+		Namespaces:       n,
+		Allow:            allow,
+		LimitThreshold:   limit,
+		RequestThreshold: request,
+	}
+}
@@ -1,53 +1,150 @@
 package kubernetes
 
 import (
+	"io/ioutil"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// inClusterConfig is swapped out in tests, as the real implementation reads from fixed
+// service account paths and environment variables only present inside a pod.
+var inClusterConfig = rest.InClusterConfig
+
+// inClusterNamespaceFile is the namespace of the pod's service account, mounted alongside its token.
+var inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// ClientOptions mirrors the kubectl connection flags used when building a client.
+// The zero value uses the in-cluster credentials or the current kubeconfig context.
+type ClientOptions struct {
+	// Kubeconfig is an explicit path to a kubeconfig file
+	Kubeconfig string
+
+	// Context is the kubeconfig context to use instead of the current context
+	Context string
+
+	// Cluster is the kubeconfig cluster to use instead of the context's cluster
+	Cluster string
+
+	// As is the username to impersonate
+	As string
+
+	// AsGroups are the groups to impersonate
+	AsGroups []string
+
+	// RequestTimeout is the time to wait for a single server request (i.e. "30s"), zero means no timeout
+	RequestTimeout string
+}
+
 // Config returns a Kubernetes Clientset depending on the kubeconfig source
-func Config(kubeconfig string) (*kubernetes.Clientset, error) {
-	if kubeconfig == "" {
-		if home := homeDir(); home != "" {
-			kubeconfig = filepath.Join(home, ".kube", "config")
-		} else {
-			return nil, errors.New("No valid kubeconfig path")
+func Config(o ClientOptions) (*kubernetes.Clientset, error) {
+	config, err := RestConfig(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(config)
+}
+
+// DynamicConfig returns a Kubernetes dynamic client depending on the kubeconfig source
+func DynamicConfig(o ClientOptions) (dynamic.Interface, error) {
+	config, err := RestConfig(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamic.NewForConfig(config)
+}
+
+// RestConfig resolves the client configuration used by both Config and DynamicConfig.
+// An explicit kubeconfig, context or cluster always takes priority, otherwise the service account
+// credentials are used when running inside a pod. Failing that, the KUBECONFIG env var
+// (including colon-separated lists of files) and then '~/.kube/config' are tried.
+func RestConfig(o ClientOptions) (*rest.Config, error) {
+	if o.Kubeconfig == "" && o.Context == "" && o.Cluster == "" {
+		config, err := inClusterConfig()
+		if err == nil {
+			return withInClusterOverrides(config, o)
+		} else if err != rest.ErrNotInCluster {
+			return nil, errors.Wrap(err, "loading in-cluster config")
 		}
 	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	config, err := clientConfig(o).ClientConfig()
 	if err != nil {
-		panic(err.Error())
+		return nil, errors.Wrap(err, "loading kubeconfig")
 	}
 
-	return kubernetes.NewForConfig(config)
+	return config, nil
 }
 
-func DynamicConfig(kubeconfig string) (dynamic.Interface, error) {
-	if kubeconfig == "" {
-		if home := homeDir(); home != "" {
-			kubeconfig = filepath.Join(home, ".kube", "config")
-		} else {
-			return nil, errors.New("No valid kubeconfig path")
+// DefaultNamespace resolves the namespace to operate in when one isn't explicitly passed.
+// Following the same priority as RestConfig, this is the pod's namespace when running in-cluster
+// (POD_NAMESPACE or the service account namespace file) and otherwise the active kubeconfig context's namespace.
+func DefaultNamespace(o ClientOptions) (string, error) {
+	if o.Kubeconfig == "" && o.Context == "" && o.Cluster == "" {
+		if _, err := inClusterConfig(); err == nil {
+			if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+				return ns, nil
+			}
+
+			data, err := ioutil.ReadFile(inClusterNamespaceFile)
+			if err != nil {
+				return "", errors.Wrap(err, "reading in-cluster namespace")
+			}
+			if ns := strings.TrimSpace(string(data)); ns != "" {
+				return ns, nil
+			}
+			return "default", nil
 		}
 	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	ns, _, err := clientConfig(o).Namespace()
 	if err != nil {
-		panic(err.Error())
+		return "", errors.Wrap(err, "loading kubeconfig namespace")
 	}
 
-	return dynamic.NewForConfig(config)
+	return ns, nil
+}
+
+// clientConfig merges the kubeconfig loading rules with the overrides from 'o'.
+func clientConfig(o ClientOptions) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.Kubeconfig
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: o.Context,
+		Context:        clientcmdapi.Context{Cluster: o.Cluster},
+		AuthInfo: clientcmdapi.AuthInfo{
+			Impersonate:       o.As,
+			ImpersonateGroups: o.AsGroups,
+		},
+		Timeout: o.RequestTimeout,
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
 }
 
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
+// withInClusterOverrides applies the impersonation and timeout options, which would otherwise
+// be handled by the kubeconfig overrides, to the in-cluster config.
+func withInClusterOverrides(config *rest.Config, o ClientOptions) (*rest.Config, error) {
+	if o.As != "" || len(o.AsGroups) > 0 {
+		config.Impersonate = rest.ImpersonationConfig{UserName: o.As, Groups: o.AsGroups}
 	}
-	return os.Getenv("USERPROFILE") // windows
+
+	if o.RequestTimeout != "" {
+		timeout, err := clientcmd.ParseTimeout(o.RequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		config.Timeout = timeout
+	}
+
+	return config, nil
 }
@@ -0,0 +1,251 @@
+package kubernetes
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	clusterKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+- name: qa
+  cluster:
+    server: https://qa.example.com:6443
+users:
+- name: dev-user
+  user:
+    token: dev-token
+`
+	contextKubeconfig = `apiVersion: v1
+kind: Config
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev-user
+- name: qa
+  context:
+    cluster: qa
+    user: dev-user
+    namespace: qa-apps
+current-context: dev
+`
+)
+
+func TestRestConfig(t *testing.T) {
+	dir := t.TempDir()
+	clusterFile := writeFile(t, dir, "cluster", clusterKubeconfig)
+	contextFile := writeFile(t, dir, "context", contextKubeconfig)
+	mergedFile := writeFile(t, dir, "merged", clusterKubeconfig+strings.TrimPrefix(contextKubeconfig, "apiVersion: v1\nkind: Config\n"))
+
+	tests := []struct {
+		name      string
+		options   ClientOptions
+		env       string
+		inCluster func() (*rest.Config, error)
+		want      rest.Config
+		wantErr   bool
+	}{
+		{
+			name:      "Uses service account credentials when running in-cluster",
+			env:       mergedFile,
+			inCluster: func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil },
+			want:      rest.Config{Host: "https://10.0.0.1:443"},
+		},
+		{
+			name:      "Explicit kubeconfig takes priority over in-cluster credentials",
+			options:   ClientOptions{Kubeconfig: mergedFile},
+			inCluster: func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil },
+			want:      rest.Config{Host: "https://dev.example.com:6443"},
+		},
+		{
+			name:      "Falls back to colon-separated KUBECONFIG files outside of a cluster",
+			env:       clusterFile + string(os.PathListSeparator) + contextFile,
+			inCluster: func() (*rest.Config, error) { return nil, rest.ErrNotInCluster },
+			want:      rest.Config{Host: "https://dev.example.com:6443"},
+		},
+		{
+			name:      "Returns in-cluster errors instead of falling back",
+			env:       mergedFile,
+			inCluster: func() (*rest.Config, error) { return nil, errors.New("reading token") },
+			wantErr:   true,
+		},
+		{
+			name:      "Returns an error for a missing kubeconfig",
+			options:   ClientOptions{Kubeconfig: filepath.Join(dir, "missing")},
+			inCluster: func() (*rest.Config, error) { return nil, rest.ErrNotInCluster },
+			wantErr:   true,
+		},
+		{
+			name:      "Explicit context takes priority over in-cluster credentials",
+			env:       mergedFile,
+			options:   ClientOptions{Context: "qa"},
+			inCluster: func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil },
+			want:      rest.Config{Host: "https://qa.example.com:6443"},
+		},
+		{
+			name:      "Cluster overrides the context's cluster",
+			env:       mergedFile,
+			options:   ClientOptions{Context: "dev", Cluster: "qa"},
+			inCluster: func() (*rest.Config, error) { return nil, rest.ErrNotInCluster },
+			want:      rest.Config{Host: "https://qa.example.com:6443"},
+		},
+		{
+			name:      "Impersonation and timeout are applied to the kubeconfig",
+			env:       mergedFile,
+			options:   ClientOptions{As: "jane", AsGroups: []string{"devs"}, RequestTimeout: "30s"},
+			inCluster: func() (*rest.Config, error) { return nil, rest.ErrNotInCluster },
+			want: rest.Config{
+				Host:        "https://dev.example.com:6443",
+				Impersonate: rest.ImpersonationConfig{UserName: "jane", Groups: []string{"devs"}},
+				Timeout:     30 * time.Second,
+			},
+		},
+		{
+			name:      "Impersonation and timeout are applied in-cluster",
+			options:   ClientOptions{As: "jane", AsGroups: []string{"devs"}, RequestTimeout: "30s"},
+			inCluster: func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil },
+			want: rest.Config{
+				Host:        "https://10.0.0.1:443",
+				Impersonate: rest.ImpersonationConfig{UserName: "jane", Groups: []string{"devs"}},
+				Timeout:     30 * time.Second,
+			},
+		},
+		{
+			name:      "Returns an error for an invalid timeout",
+			options:   ClientOptions{RequestTimeout: "soon"},
+			inCluster: func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil },
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setEnv(t, "KUBECONFIG", tt.env)()
+			defer func(f func() (*rest.Config, error)) { inClusterConfig = f }(inClusterConfig)
+			inClusterConfig = tt.inCluster
+
+			got, err := RestConfig(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RestConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			if got.Host != tt.want.Host {
+				t.Errorf("RestConfig() host = %v, want %v", got.Host, tt.want.Host)
+			}
+			if diff := cmp.Diff(got.Impersonate, tt.want.Impersonate, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.want.Impersonate, diff)
+			}
+			if got.Timeout != tt.want.Timeout {
+				t.Errorf("RestConfig() timeout = %v, want %v", got.Timeout, tt.want.Timeout)
+			}
+		})
+	}
+}
+
+func TestDefaultNamespace(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := writeFile(t, dir, "config", clusterKubeconfig+strings.TrimPrefix(contextKubeconfig, "apiVersion: v1\nkind: Config\n"))
+	namespaceFile := writeFile(t, dir, "namespace", "team-a\n")
+	inCluster := func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil }
+	notInCluster := func() (*rest.Config, error) { return nil, rest.ErrNotInCluster }
+
+	tests := []struct {
+		name      string
+		options   ClientOptions
+		inCluster func() (*rest.Config, error)
+		env       string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "Uses the service account namespace in-cluster",
+			inCluster: inCluster,
+			want:      "team-a",
+		},
+		{
+			name:      "Prefers POD_NAMESPACE over the service account namespace in-cluster",
+			inCluster: inCluster,
+			env:       "team-b",
+			want:      "team-b",
+		},
+		{
+			name:      "Falls back to 'default' when the context has no namespace",
+			options:   ClientOptions{Kubeconfig: kubeconfig},
+			inCluster: notInCluster,
+			want:      "default",
+		},
+		{
+			name:      "Uses the namespace of the selected context",
+			options:   ClientOptions{Kubeconfig: kubeconfig, Context: "qa"},
+			inCluster: inCluster,
+			want:      "qa-apps",
+		},
+		{
+			name:      "Returns an error for an unknown context",
+			options:   ClientOptions{Kubeconfig: kubeconfig, Context: "missing"},
+			inCluster: notInCluster,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setEnv(t, "POD_NAMESPACE", tt.env)()
+			defer func(f func() (*rest.Config, error), n string) {
+				inClusterConfig, inClusterNamespaceFile = f, n
+			}(inClusterConfig, inClusterNamespaceFile)
+			inClusterConfig, inClusterNamespaceFile = tt.inCluster, namespaceFile
+
+			got, err := DefaultNamespace(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DefaultNamespace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DefaultNamespace() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// setEnv sets an environment variable and returns a func restoring its previous value.
+func setEnv(t *testing.T, key, value string) func() {
+	previous, found := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() {
+		if found {
+			_ = os.Setenv(key, previous)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// OrphanedAutoscalers returns the HorizontalPodAutoscalers in the namespace 'n' whose scaleTargetRef no longer exists.
+// The target's kind is resolved with the RESTMapper 'm', so any scalable type (i.e. Argo Rollouts) is supported.
+func OrphanedAutoscalers(c dynamic.Interface, m meta.RESTMapper, n string, a []string) ([]Resource, error) {
+	list, err := c.Resource(HorizontalPodAutoscalerSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := existenceCache(c)
+	var resource []Resource
+	for _, hpa := range list.Items {
+		if stringContainsArrayElement(hpa.GetName(), a) {
+			continue
+		}
+
+		target, served, err := scaleTarget(m, hpa)
+		if err != nil {
+			return nil, err
+		}
+
+		// a target of a type which isn't served can't exist
+		missing := []string{target.String()}
+		if served {
+			missing, err = missingRefs(exists, []objectRef{target})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(missing) > 0 {
+			orphan, err := orphanedResource(hpa, HorizontalPodAutoscalerSchema, nsProtection, now, missing)
+			if err != nil {
+				return nil, err
+			}
+			resource = append(resource, orphan)
+		}
+	}
+
+	return resource, nil
+}
+
+// scaleTarget returns the object targeted by the autoscaler's 'spec.scaleTargetRef', and whether its type is served.
+// When it isn't, the reference uses the lowercase kind in place of the resource.
+func scaleTarget(m meta.RESTMapper, hpa unstructured.Unstructured) (objectRef, bool, error) {
+	ref, _, err := unstructured.NestedStringMap(hpa.Object, "spec", "scaleTargetRef")
+	if err != nil {
+		return objectRef{}, false, err
+	}
+
+	gv, err := schema.ParseGroupVersion(ref["apiVersion"])
+	if err != nil {
+		return objectRef{}, false, errors.Wrapf(err, "parsing scaleTargetRef of %s", hpa.GetName())
+	}
+
+	mapping, err := m.RESTMapping(gv.WithKind(ref["kind"]).GroupKind(), gv.Version)
+	if meta.IsNoMatchError(err) {
+		resource := schema.GroupVersionResource{Resource: strings.ToLower(ref["kind"])}
+		return objectRef{resource, hpa.GetNamespace(), ref["name"]}, false, nil
+	} else if err != nil {
+		return objectRef{}, false, err
+	}
+
+	return objectRef{mapping.Resource, hpa.GetNamespace(), ref["name"]}, true, nil
+}
@@ -0,0 +1,45 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestOrphanedAutoscalers(t *testing.T) {
+	hpa := func(name, api, kind, target string) runtime.Object {
+		h := newResource("autoscaling/v1", "HorizontalPodAutoscaler", name)
+		h.Object["spec"] = map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"apiVersion": api, "kind": kind, "name": target},
+			"maxReplicas":    int64(5),
+		}
+		return h
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newResource("apps/v1", "Deployment", "web"),
+		newResource("argoproj.io/v1alpha1", "Rollout", "canary"),
+		hpa("web", "apps/v1", "Deployment", "web"),
+		hpa("canary", "argoproj.io/v1alpha1", "Rollout", "canary"),
+		hpa("removed", "apps/v1", "StatefulSet", "removed"),
+		hpa("uninstalled", "example.com/v1", "Workload", "uninstalled"),
+	)
+
+	m := NewRESTMapper(&fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: discoveryResources}})
+	autoscalers, err := OrphanedAutoscalers(client, m, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"removed":     {"statefulsets/default/removed"},
+		"uninstalled": {"workload/default/uninstalled"},
+	}
+	if diff := cmp.Diff(missing(autoscalers), expected); diff != "" {
+		t.Errorf("OrphanedAutoscalers() differ (-got, +want): %s", diff)
+	}
+}
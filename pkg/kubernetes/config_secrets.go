@@ -1,70 +1,204 @@
 package kubernetes
 
 import (
-	"context"
 	"github.com/pkg/errors"
-	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	core_v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 )
 
-// UsedConfigAndSecrets returns two maps of configmaps and secrets currently in use by existing pods.
-// NB: This needs broken up to limit the amount of nesting.
-// After retrieving the existing pods, it looks at containers.envFrom and volumes for configmap/secret references.
-// Any found references are placed into the respective maps (with the key as their metadata.name)
-func UsedConfigAndSecrets(c dynamic.Interface, n string) (map[string]bool, map[string]bool, error) {
-	list, err := c.Resource(PodSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+// podSpecSources are the resource types holding a pod spec, alongside the path to it within their objects.
+// Workload templates are included so references from workloads scaled to zero or suspended are still found.
+// Older API versions are listed after the preferred version of a type, and only used when it isn't served.
+var podSpecSources = []struct {
+	resources []schema.GroupVersionResource
+	fields    []string
+}{
+	{[]schema.GroupVersionResource{PodSchema}, []string{"spec"}},
+	{[]schema.GroupVersionResource{DeploymentSchema}, []string{"spec", "template", "spec"}},
+	{[]schema.GroupVersionResource{StatefulSetSchema}, []string{"spec", "template", "spec"}},
+	{[]schema.GroupVersionResource{DaemonSetSchema}, []string{"spec", "template", "spec"}},
+	{[]schema.GroupVersionResource{ReplicaSetSchema}, []string{"spec", "template", "spec"}},
+	{[]schema.GroupVersionResource{JobSchema}, []string{"spec", "template", "spec"}},
+	{[]schema.GroupVersionResource{CronJobSchema, CronJobV1Beta1Schema}, []string{"spec", "jobTemplate", "spec", "template", "spec"}},
+}
+
+// UsedConfigAndSecrets returns the configmaps and secrets currently referenced in the namespace 'n', by every ReferenceProvider.
+// Any found references are keyed by their metadata.name, with the sorted list of objects referencing them.
+func UsedConfigAndSecrets(c dynamic.Interface, n string) (References, References, error) {
+	configs := make(References)
+	secrets := make(References)
+
+	for _, provider := range ReferenceProviders {
+		providerConfigs, providerSecrets, err := provider(c, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		configs.merge(providerConfigs)
+		secrets.merge(providerSecrets)
+	}
+
+	configs.sort()
+	secrets.sort()
+	return configs, secrets, nil
+}
+
+// PodSpecReferences is a ReferenceProvider for pods and workload templates.
+// Every PodSpec field which can reference a configmap or secret is checked (see podSpecReferences).
+func PodSpecReferences(c dynamic.Interface, n string) (References, References, error) {
+	configs := make(References)
+	secrets := make(References)
+
+	err := forEachPodSpec(c, n, func(by string, _ map[string]string, spec core_v1.PodSpec) {
+		itemConfigs, itemSecrets := podSpecReferences(spec)
+		configs.add(by, itemConfigs...)
+		secrets.add(by, itemSecrets...)
+	})
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "error getting pods")
+		return nil, nil, err
 	}
 
-	secrets := make(map[string]bool)
-	configs := make(map[string]bool)
+	return configs, secrets, nil
+}
 
-	for _, pod := range list.Items {
-		containers, found, err := unstructured.NestedSlice(pod.Object, "spec", "containers")
-		if err != nil || !found {
-			return nil, nil, err
+// forEachPodSpec calls 'f' with the pod spec of every pod and workload template (see podSpecSources) in the namespace 'n',
+// alongside the object holding it (i.e. "deployments/web") and the labels of the pod (or template).
+func forEachPodSpec(c dynamic.Interface, n string, f func(by string, labels map[string]string, spec core_v1.PodSpec)) error {
+	for _, source := range podSpecSources {
+		list, err := listServed(c, n, source.resources...)
+		if err != nil {
+			return errors.Wrapf(err, "error getting %s", source.resources[0].Resource)
 		}
 
-		for _, container := range containers {
-			envs, found, err := unstructured.NestedSlice(container.(map[string]interface{}), "envFrom")
+		for _, item := range list.Items {
+			spec, err := podSpec(item, source.fields...)
 			if err != nil {
-				return nil, nil, err
-			} else if found {
-				for _, env := range envs {
-					config, found, err := unstructured.NestedString(env.(map[string]interface{}), "configMapRef", "name")
-					if err != nil {
-						return nil, nil, err
-					} else if found {
-						configs[config] = true
-					}
-				}
+				return errors.Wrapf(err, "parsing %s %s", source.resources[0].Resource, item.GetName())
 			}
+
+			// the pod's labels are alongside its spec, i.e. 'spec.template.metadata.labels'
+			labelFields := append(append([]string{}, source.fields[:len(source.fields)-1]...), "metadata", "labels")
+			labels, _, err := unstructured.NestedStringMap(item.Object, labelFields...)
+			if err != nil {
+				return errors.Wrapf(err, "parsing %s %s", source.resources[0].Resource, item.GetName())
+			}
+
+			f(owner(source.resources[0], item), labels, spec)
 		}
+	}
 
-		volumes, found, err := unstructured.NestedSlice(pod.Object, "spec", "volumes")
-		if err != nil {
-			return nil, nil, err
-		} else if !found {
-			continue
+	return nil
+}
+
+// podSpec converts the pod spec found at 'fields' within the object 'obj' to its typed equivalent.
+func podSpec(obj unstructured.Unstructured, fields ...string) (core_v1.PodSpec, error) {
+	var spec core_v1.PodSpec
+	m, found, err := unstructured.NestedMap(obj.Object, fields...)
+	if err != nil || !found {
+		return spec, err
+	}
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(m, &spec)
+	return spec, err
+}
+
+// podSpecReferences returns the names of the configmaps and secrets referenced by the pod spec 's'.
+// This covers the env and envFrom of all containers (including init and ephemeral containers), volumes
+// (including projected volumes and the credentials of volume plugins) and imagePullSecrets.
+// Optional references are included, as the pod will use them if they exist.
+func podSpecReferences(s core_v1.PodSpec) (configs []string, secrets []string) {
+	var envs [][]core_v1.EnvVar
+	var envFroms [][]core_v1.EnvFromSource
+	for _, container := range append(append([]core_v1.Container{}, s.InitContainers...), s.Containers...) {
+		envs = append(envs, container.Env)
+		envFroms = append(envFroms, container.EnvFrom)
+	}
+	for _, container := range s.EphemeralContainers {
+		envs = append(envs, container.Env)
+		envFroms = append(envFroms, container.EnvFrom)
+	}
+
+	for _, env := range envs {
+		for _, e := range env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if ref := e.ValueFrom.ConfigMapKeyRef; ref != nil {
+				configs = append(configs, ref.Name)
+			}
+			if ref := e.ValueFrom.SecretKeyRef; ref != nil {
+				secrets = append(secrets, ref.Name)
+			}
 		}
+	}
 
-		for _, volume := range volumes {
-			config, found, err := unstructured.NestedString(volume.(map[string]interface{}), "configMap", "name")
-			if err != nil {
-				return nil, nil, err
-			} else if found {
-				configs[config] = true
+	for _, envFrom := range envFroms {
+		for _, e := range envFrom {
+			if e.ConfigMapRef != nil {
+				configs = append(configs, e.ConfigMapRef.Name)
+			}
+			if e.SecretRef != nil {
+				secrets = append(secrets, e.SecretRef.Name)
 			}
+		}
+	}
 
-			secret, found, err := unstructured.NestedString(volume.(map[string]interface{}), "secret", "secretName")
-			if err != nil {
-				return nil, nil, err
-			} else if found {
-				secrets[secret] = true
+	for _, volume := range s.Volumes {
+		volumeConfigs, volumeSecrets := volumeReferences(volume.VolumeSource)
+		configs = append(configs, volumeConfigs...)
+		secrets = append(secrets, volumeSecrets...)
+	}
+
+	for _, ref := range s.ImagePullSecrets {
+		secrets = append(secrets, ref.Name)
+	}
+
+	return configs, secrets
+}
+
+// volumeReferences returns the names of the configmaps and secrets referenced by the volume 'v'.
+func volumeReferences(v core_v1.VolumeSource) (configs []string, secrets []string) {
+	if v.ConfigMap != nil {
+		configs = append(configs, v.ConfigMap.Name)
+	}
+	if v.Secret != nil {
+		secrets = append(secrets, v.Secret.SecretName)
+	}
+
+	if v.Projected != nil {
+		for _, source := range v.Projected.Sources {
+			if source.ConfigMap != nil {
+				configs = append(configs, source.ConfigMap.Name)
+			}
+			if source.Secret != nil {
+				secrets = append(secrets, source.Secret.Name)
 			}
 		}
 	}
-	return configs, secrets, nil
-}
\ No newline at end of file
+
+	// volume plugins referencing secrets for their credentials
+	switch {
+	case v.AzureFile != nil:
+		secrets = append(secrets, v.AzureFile.SecretName)
+	case v.CephFS != nil && v.CephFS.SecretRef != nil:
+		secrets = append(secrets, v.CephFS.SecretRef.Name)
+	case v.Cinder != nil && v.Cinder.SecretRef != nil:
+		secrets = append(secrets, v.Cinder.SecretRef.Name)
+	case v.FlexVolume != nil && v.FlexVolume.SecretRef != nil:
+		secrets = append(secrets, v.FlexVolume.SecretRef.Name)
+	case v.ISCSI != nil && v.ISCSI.SecretRef != nil:
+		secrets = append(secrets, v.ISCSI.SecretRef.Name)
+	case v.RBD != nil && v.RBD.SecretRef != nil:
+		secrets = append(secrets, v.RBD.SecretRef.Name)
+	case v.ScaleIO != nil && v.ScaleIO.SecretRef != nil:
+		secrets = append(secrets, v.ScaleIO.SecretRef.Name)
+	case v.StorageOS != nil && v.StorageOS.SecretRef != nil:
+		secrets = append(secrets, v.StorageOS.SecretRef.Name)
+	case v.CSI != nil && v.CSI.NodePublishSecretRef != nil:
+		secrets = append(secrets, v.CSI.NodePublishSecretRef.Name)
+	}
+
+	return configs, secrets
+}
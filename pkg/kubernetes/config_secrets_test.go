@@ -16,8 +16,8 @@ var (
 
 func TestResourcesInUse(t *testing.T) {
 	scheme := runtime.NewScheme()
-	expectedConfigs := map[string]bool{"properties": true, "env-vars": true}
-	expectedSecrets := map[string]bool{"tokens": true}
+	expectedConfigs := References{"properties": {"pods/config-pod"}, "env-vars": {"pods/env-pod"}}
+	expectedSecrets := References{"tokens": {"pods/config-pod"}}
 
 	client := fake.NewSimpleDynamicClient(scheme,
 		newPodWithVolumes("config-pod", "properties", "tokens"),
@@ -43,6 +43,132 @@ func TestResourcesInUse(t *testing.T) {
 
 }
 
+func TestResourcesInUseAcrossPodSpec(t *testing.T) {
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "key": "value"}
+	}
+
+	pod := newResource("v1", "Pod", "full-pod")
+	pod.Object["spec"] = map[string]interface{}{
+		"initContainers": []interface{}{
+			map[string]interface{}{
+				"name": "init",
+				"env": []interface{}{
+					map[string]interface{}{"name": "INIT", "valueFrom": map[string]interface{}{"configMapKeyRef": ref("init-config")}},
+				},
+			},
+		},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name": "app",
+				"env": []interface{}{
+					map[string]interface{}{"name": "PLAIN", "value": "plain"},
+					map[string]interface{}{"name": "PASSWORD", "valueFrom": map[string]interface{}{"secretKeyRef": ref("env-secret")}},
+				},
+				"envFrom": []interface{}{
+					map[string]interface{}{"secretRef": map[string]interface{}{"name": "env-from-secret"}},
+				},
+			},
+		},
+		"ephemeralContainers": []interface{}{
+			map[string]interface{}{
+				"name": "debug",
+				"envFrom": []interface{}{
+					map[string]interface{}{"configMapRef": map[string]interface{}{"name": "debug-config"}},
+				},
+			},
+		},
+		"volumes": []interface{}{
+			map[string]interface{}{
+				"name": "projected",
+				"projected": map[string]interface{}{
+					"sources": []interface{}{
+						map[string]interface{}{"configMap": map[string]interface{}{"name": "projected-config"}},
+						map[string]interface{}{"secret": map[string]interface{}{"name": "projected-secret"}},
+					},
+				},
+			},
+			map[string]interface{}{
+				"name": "csi",
+				"csi": map[string]interface{}{
+					"driver":               "secrets-store.csi.k8s.io",
+					"nodePublishSecretRef": map[string]interface{}{"name": "csi-secret"},
+				},
+			},
+		},
+		"imagePullSecrets": []interface{}{
+			map[string]interface{}{"name": "registry-secret"},
+		},
+	}
+
+	configs, secrets, err := UsedConfigAndSecrets(fake.NewSimpleDynamicClient(runtime.NewScheme(), pod), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedConfigs := References{"init-config": {"pods/full-pod"}, "debug-config": {"pods/full-pod"}, "projected-config": {"pods/full-pod"}}
+	if diff := cmp.Diff(configs, expectedConfigs); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expectedConfigs, diff)
+	}
+
+	expectedSecrets := References{
+		"env-secret":       {"pods/full-pod"},
+		"env-from-secret":  {"pods/full-pod"},
+		"projected-secret": {"pods/full-pod"},
+		"csi-secret":       {"pods/full-pod"},
+		"registry-secret":  {"pods/full-pod"},
+	}
+	if diff := cmp.Diff(secrets, expectedSecrets); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expectedSecrets, diff)
+	}
+}
+
+func TestResourcesInUseByTemplates(t *testing.T) {
+	volumes := func(config string) map[string]interface{} {
+		return map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app"}},
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": config}},
+			},
+		}
+	}
+	template := func(config string) map[string]interface{} {
+		return map[string]interface{}{"template": map[string]interface{}{"spec": volumes(config)}}
+	}
+
+	deployment := newResource("apps/v1", "Deployment", "web")
+	deployment.Object["spec"] = template("shared-config")
+	statefulSet := newResource("apps/v1", "StatefulSet", "db")
+	statefulSet.Object["spec"] = template("db-config")
+	daemonSet := newResource("apps/v1", "DaemonSet", "agent")
+	daemonSet.Object["spec"] = template("agent-config")
+	replicaSet := newResource("apps/v1", "ReplicaSet", "web-5d8f")
+	replicaSet.Object["spec"] = template("shared-config")
+	job := newResource("batch/v1", "Job", "migrate")
+	job.Object["spec"] = template("migrate-config")
+	cronJob := newResource("batch/v1", "CronJob", "report")
+	cronJob.Object["spec"] = map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": template("report-config")}}
+	pod := newResource("v1", "Pod", "web-5d8f-x2v9")
+	pod.Object["spec"] = volumes("shared-config")
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), deployment, statefulSet, daemonSet, replicaSet, job, cronJob, pod)
+	configs, _, err := UsedConfigAndSecrets(client, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := References{
+		"shared-config":  {"deployments/web", "pods/web-5d8f-x2v9", "replicasets/web-5d8f"},
+		"db-config":      {"statefulsets/db"},
+		"agent-config":   {"daemonsets/agent"},
+		"migrate-config": {"jobs/migrate"},
+		"report-config":  {"cronjobs/report"},
+	}
+	if diff := cmp.Diff(configs, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
 func newConfigmap(name string) *unstructured.Unstructured {
 	return newResource("v1", "configmap", name)
 }
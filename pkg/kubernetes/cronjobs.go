@@ -0,0 +1,183 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// Suspended CronJobs won't schedule any more Jobs
+	Suspended Status = "Suspended"
+
+	// NoRecentSuccess CronJobs haven't had a successful Job within the targeted window
+	NoRecentSuccess Status = "NoRecentSuccess"
+)
+
+// The history limits Kubernetes uses when a CronJob doesn't set its own
+const (
+	defaultSuccessfulJobsHistoryLimit = 3
+	defaultFailedJobsHistoryLimit     = 1
+)
+
+// CronJobHistory returns the finished Jobs in the namespace 'n' kept as the history of the CronJob owning them,
+// keyed by name with their CronJob (i.e. "cronjobs/nightly").
+// The newest 'successful' completed and 'failed' failed Jobs are kept per CronJob. When either is negative,
+// the CronJob's own successfulJobsHistoryLimit (or failedJobsHistoryLimit) is used instead.
+func CronJobHistory(c dynamic.Interface, n string, successful, failed int) (References, error) {
+	cronJobs, err := listServed(c, n, CronJobSchema, CronJobV1Beta1Schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting cronjobs")
+	}
+
+	jobs, err := cronJobJobs(c, n)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(References)
+	for _, cronJob := range cronJobs.Items {
+		keep := map[Status]int{Completed: successful, Failed: failed}
+		if successful < 0 {
+			keep[Completed] = historyLimit(cronJob, "successfulJobsHistoryLimit", defaultSuccessfulJobsHistoryLimit)
+		}
+		if failed < 0 {
+			keep[Failed] = historyLimit(cronJob, "failedJobsHistoryLimit", defaultFailedJobsHistoryLimit)
+		}
+
+		for _, job := range jobs[cronJob.GetName()] {
+			status, err := objectStatus(job)
+			if err != nil {
+				return nil, err
+			}
+
+			if limit, finished := keep[status]; finished && limit > 0 {
+				history.add(owner(CronJobSchema, cronJob), job.GetName())
+				keep[status] = limit - 1
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// StaleCronJobs returns the CronJobs in the namespace 'n' which are suspended, or haven't had a successful Job within 'window'.
+// The last success is taken from 'status.lastSuccessfulTime', or the newest completed Job on clusters which don't set it.
+// CronJobs created within the window are given time to succeed. A zero 'window' only returns suspended CronJobs.
+func StaleCronJobs(c dynamic.Interface, n string, window time.Duration, a []string) ([]Resource, error) {
+	cronJobs, err := listServed(c, n, CronJobSchema, CronJobV1Beta1Schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	jobs, err := cronJobJobs(c, n)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource []Resource
+	for _, cronJob := range cronJobs.Items {
+		if stringContainsArrayElement(cronJob.GetName(), a) {
+			continue
+		}
+
+		lastSuccess, err := lastSuccessfulTime(cronJob, jobs[cronJob.GetName()])
+		if err != nil {
+			return nil, err
+		}
+
+		var status Status
+		if suspended, _, _ := unstructured.NestedBool(cronJob.Object, "spec", "suspend"); suspended {
+			status = Suspended
+		} else if window != 0 && now.Sub(lastSuccess) > window && now.Sub(cronJob.GetCreationTimestamp().Time) > window {
+			status = NoRecentSuccess
+		} else {
+			continue
+		}
+
+		details := "lastSuccessfulTime=never"
+		if !lastSuccess.IsZero() {
+			details = "lastSuccessfulTime=" + lastSuccess.UTC().Format(time.RFC3339)
+		}
+
+		age, err := objectAge(cronJob)
+		if err != nil {
+			return nil, err
+		}
+
+		resource = append(resource, Resource{
+			Name:      cronJob.GetName(),
+			Kind:      CronJobSchema.Resource,
+			Age:       age.Round(time.Minute),
+			Status:    status,
+			Protected: objectProtection(&cronJob, nsProtection, now),
+			Details:   details,
+		})
+	}
+
+	return resource, nil
+}
+
+// cronJobJobs returns the Jobs in the namespace 'n' owned by a CronJob, keyed by its name and sorted newest first.
+func cronJobJobs(c dynamic.Interface, n string) (map[string][]unstructured.Unstructured, error) {
+	list, err := c.Resource(JobSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting jobs")
+	}
+
+	jobs := make(map[string][]unstructured.Unstructured)
+	for _, job := range list.Items {
+		if ref := meta_v1.GetControllerOf(&job); ref != nil && ref.Kind == "CronJob" {
+			jobs[ref.Name] = append(jobs[ref.Name], job)
+		}
+	}
+
+	for _, owned := range jobs {
+		sort.SliceStable(owned, func(i, j int) bool {
+			return owned[i].GetCreationTimestamp().After(owned[j].GetCreationTimestamp().Time)
+		})
+	}
+	return jobs, nil
+}
+
+// lastSuccessfulTime returns when the CronJob last succeeded, or zero if it never has.
+// 'jobs' are the CronJob's Jobs sorted newest first, used when 'status.lastSuccessfulTime' isn't set.
+func lastSuccessfulTime(cronJob unstructured.Unstructured, jobs []unstructured.Unstructured) (time.Time, error) {
+	if s, found, _ := unstructured.NestedString(cronJob.Object, "status", "lastSuccessfulTime"); found {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to parse 'lastSuccessfulTime' of %s: %s", cronJob.GetName(), err)
+		}
+		return t, nil
+	}
+
+	for _, job := range jobs {
+		status, err := objectStatus(job)
+		if err != nil {
+			return time.Time{}, err
+		} else if status == Completed {
+			return jobFinishedTime(job, status)
+		}
+	}
+	return time.Time{}, nil
+}
+
+// historyLimit returns the CronJob's history limit 'field' (i.e. "successfulJobsHistoryLimit"), or 'fallback' when unset.
+func historyLimit(cronJob unstructured.Unstructured, field string, fallback int) int {
+	if limit, found, _ := unstructured.NestedInt64(cronJob.Object, "spec", field); found {
+		return int(limit)
+	}
+	return fallback
+}
@@ -0,0 +1,127 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestCronJobHistory(t *testing.T) {
+	nightly := newCronJob("nightly", map[string]interface{}{"successfulJobsHistoryLimit": int64(2)}, nil)
+	hourly := newCronJob("hourly", map[string]interface{}{}, nil)
+
+	objects := []runtime.Object{nightly, hourly,
+		newCronJobJob(newCompletedJob("nightly-1"), "nightly", 4*time.Hour),
+		newCronJobJob(newFailedJob("nightly-2"), "nightly", 3*time.Hour),
+		newCronJobJob(newCompletedJob("nightly-3"), "nightly", 2*time.Hour),
+		newCronJobJob(newCompletedJob("nightly-4"), "nightly", 1*time.Hour),
+		newCronJobJob(newFailedJob("hourly-1"), "hourly", 2*time.Hour),
+		newCronJobJob(newFailedJob("hourly-2"), "hourly", 1*time.Hour),
+		newCompletedJob("manual"),
+	}
+
+	tests := []struct {
+		name               string
+		successful, failed int
+		want               References
+	}{
+		{
+			name:       "The CronJob's history limits are used when negative",
+			successful: -1,
+			failed:     -1,
+			want: References{
+				"nightly-4": {"cronjobs/nightly"},
+				"nightly-3": {"cronjobs/nightly"},
+				"nightly-2": {"cronjobs/nightly"},
+				"hourly-2":  {"cronjobs/hourly"},
+			},
+		},
+		{
+			name:       "The newest successful and failed Jobs are kept per CronJob",
+			successful: 1,
+			failed:     0,
+			want: References{
+				"nightly-4": {"cronjobs/nightly"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+			history, err := CronJobHistory(client, "default", tt.successful, tt.failed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(history, tt.want); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.want, diff)
+			}
+		})
+	}
+}
+
+func TestStaleCronJobs(t *testing.T) {
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	yesterday := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+
+	// clusters older than v1.21 don't set 'lastSuccessfulTime', so the CronJob's Jobs are used instead
+	legacy := newCronJob("legacy", map[string]interface{}{}, nil)
+	legacy.SetCreationTimestamp(meta_v1.NewTime(time.Now().Add(-30 * 24 * time.Hour)))
+	never := newCronJob("never", map[string]interface{}{}, nil)
+	never.SetCreationTimestamp(meta_v1.NewTime(time.Now().Add(-30 * 24 * time.Hour)))
+
+	failing := newCronJob("failing", map[string]interface{}{}, map[string]interface{}{"lastSuccessfulTime": lastWeek})
+	failing.SetCreationTimestamp(meta_v1.NewTime(time.Now().Add(-30 * 24 * time.Hour)))
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newCronJob("healthy", map[string]interface{}{}, map[string]interface{}{"lastSuccessfulTime": yesterday}),
+		newCronJob("paused", map[string]interface{}{"suspend": true}, map[string]interface{}{"lastSuccessfulTime": yesterday}),
+		newCronJob("new", map[string]interface{}{}, nil),
+		failing, legacy, never,
+		newCronJobJob(newCompletedJob("legacy-1"), "legacy", 24*time.Hour),
+		newCronJobJob(newFailedJob("never-1"), "never", 24*time.Hour),
+	)
+
+	cronJobs, err := StaleCronJobs(client, "default", 72*time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"paused":  string(Suspended) + " lastSuccessfulTime=" + yesterday,
+		"failing": string(NoRecentSuccess) + " lastSuccessfulTime=" + lastWeek,
+		"never":   string(NoRecentSuccess) + " lastSuccessfulTime=never",
+	}
+
+	got := make(map[string]string)
+	for _, cronJob := range cronJobs {
+		got[cronJob.Name] = string(cronJob.Status) + " " + cronJob.Details
+	}
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("StaleCronJobs() differ (-got, +want): %s", diff)
+	}
+}
+
+func newCronJob(name string, spec, status map[string]interface{}) *unstructured.Unstructured {
+	cronJob := newResource("batch/v1", "CronJob", name)
+	spec["schedule"] = "0 * * * *"
+	cronJob.Object["spec"] = spec
+	if status != nil {
+		cronJob.Object["status"] = status
+	}
+	return cronJob
+}
+
+// newCronJobJob makes 'job' owned by the CronJob 'cronJob', created 'ago'.
+func newCronJobJob(job *unstructured.Unstructured, cronJob string, ago time.Duration) *unstructured.Unstructured {
+	controller := true
+	job.SetOwnerReferences([]meta_v1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: cronJob, Controller: &controller}})
+	job.SetCreationTimestamp(meta_v1.NewTime(time.Now().Add(-ago)))
+	return job
+}
@@ -2,20 +2,63 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/xrash/smetrics"
+	appsv1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"log"
-	"regexp"
 )
 
-// ListDuplicateDeployments finds potential duplicate deployments from similar labels
-// i.e. [dev, release, john4, dev2, john5] should match [dev, dev2] and [john4, john5]
+// The weights of each comparison in the similarity score of two deployments.
+// Comparisons without anything to compare on either side (i.e. neither sets env vars) are left out of the score.
+const (
+	instanceWeight = 0.4
+	imageWeight    = 0.3
+	envWeight      = 0.2
+	requestsWeight = 0.1
+)
+
+// nonLetters are removed from instance labels before comparing, so numbered copies (i.e. dev, dev2) match
+var nonLetters = regexp.MustCompile("[^a-zA-Z]+")
+
+// Similarity is the weighted similarity score (0-1) of a deployment to another, by its 'Instance' label value.
+type Similarity struct {
+	Instance string
+	Score    float64
+
+	// Scores are the individual comparisons making up the score, in the order of their weights
+	Scores []Score
+}
+
+// Score is the result (0-1) of a single comparison between deployments, i.e. {"image", 0.7}.
+type Score struct {
+	Name  string
+	Value float64
+}
+
+func (s Similarity) String() string {
+	scores := make([]string, len(s.Scores))
+	for i, score := range s.Scores {
+		scores[i] = fmt.Sprintf("%s=%.2f", score.Name, score.Value)
+	}
+	return fmt.Sprintf("%s: %s", s.Instance, strings.Join(scores, " "))
+}
+
+// ListDuplicateDeployments finds potential duplicate deployments, which have a weighted similarity score of at least
+// 'threshold' from their instance labels, container images, env vars and resource requests.
+// i.e. instances [dev, release, john4, dev2, john5] of the same image should match [dev, dev2] and [john4, john5]
 func ListDuplicateDeployments(clientset kubernetes.Interface,
 	namespace string,
 	appLabel string,
-	instanceLabel string) (map[string][]string, error) {
+	instanceLabel string,
+	threshold float64) (map[string][]Similarity, error) {
 	listopt := meta_v1.ListOptions{
 		LabelSelector: appLabel,
 	}
@@ -28,48 +71,195 @@ func ListDuplicateDeployments(clientset kubernetes.Interface,
 	// Copy all 'instance' labels into a string slice for iteration
 	// Copy all 'instance' labels into a map to store with their matches
 	var instances = []string{}
-	similar := make(map[string][]string)
+	similar := make(map[string][]Similarity)
 	for _, deployment := range list.Items {
 		instances = append(instances, deployment.ObjectMeta.Labels[instanceLabel])
-		similar[deployment.ObjectMeta.Labels[instanceLabel]] = []string{}
+		similar[deployment.ObjectMeta.Labels[instanceLabel]] = []Similarity{}
 	}
 
-	// Regex to remove anything but characters
-	reg, err := regexp.Compile("[^a-zA-Z]+")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// fmt.Printf("Found Instances: %s \n", instances)
-
-	// Copy []instances into a map (key: name, value: similar names).
+	// Copy []instances into a map (key: name, value: similar deployments).
 	// For each "version" iterate through the []instances to find matches.
 	// If a match is found, add it to the coresponding map[target] value slice
 	// and remove the 'match' (as a key) from the map.
 	// If a match hasn't been found, remove the 'target' (as a key) from the map.
-	for _, v := range instances {
+	for i, v := range instances {
 		if _, ok := similar[v]; !ok {
 			continue
 		}
 
-		matched := false
 		for n, j := range instances {
-			if v != j && smetrics.JaroWinkler(reg.ReplaceAllString(v, ""), j, 0.7, 4) > 0.9 {
-				similar[v] = append(similar[v], j)
-				delete(similar, j)
-				matched = true
+			if v == j {
+				continue
 			}
-			if n == len(instances)-1 && !matched {
-				// fmt.Printf("End of list and no matches - removing %s\n", v)
-				delete(similar, v)
+
+			s := similarity(list.Items[i], list.Items[n], instanceLabel)
+			if s.Score >= threshold {
+				similar[v] = append(similar[v], s)
+				delete(similar, j)
 			}
 		}
-	}
 
-	// fmt.Printf("Result Map: %s\n", similar)
+		if len(similar[v]) == 0 {
+			delete(similar, v)
+		}
+	}
 
 	// NB: If we make this concurrent by taking chucks of []instances
 	// It'll still need a final last to ensure all the chucks are filtered together
 
 	return similar, nil
 }
+
+// similarity compares the deployment 'b' to 'a', weighting the score of each comparison.
+func similarity(a, b appsv1.Deployment, instanceLabel string) Similarity {
+	s := Similarity{Instance: b.Labels[instanceLabel]}
+	weights := 0.0
+	add := func(name string, weight float64) func(float64, bool) {
+		return func(value float64, compared bool) {
+			if compared {
+				s.Scores = append(s.Scores, Score{Name: name, Value: value})
+				s.Score += value * weight
+				weights += weight
+			}
+		}
+	}
+
+	ac, bc := a.Spec.Template.Spec.Containers, b.Spec.Template.Spec.Containers
+	add("instance", instanceWeight)(instanceSimilarity(a.Labels[instanceLabel], b.Labels[instanceLabel]), true)
+	add("image", imageWeight)(imageSimilarity(ac, bc))
+	add("env", envWeight)(jaccard(envVars(ac), envVars(bc)))
+	add("requests", requestsWeight)(requestsSimilarity(resourceRequests(ac), resourceRequests(bc)))
+
+	s.Score /= weights
+	return s
+}
+
+// instanceSimilarity compares instance label values with Jaro-Winkler, ignoring anything but letters.
+func instanceSimilarity(a, b string) float64 {
+	return smetrics.JaroWinkler(nonLetters.ReplaceAllString(a, ""), nonLetters.ReplaceAllString(b, ""), 0.7, 4)
+}
+
+// imageSimilarity matches each container image to the most similar image of the other deployment, in both directions.
+// The same repository scores 0.7, and the same repository and tag (or digest) scores 1.
+func imageSimilarity(a, b []core_v1.Container) (float64, bool) {
+	if len(a) == 0 && len(b) == 0 {
+		return 0, false
+	} else if len(a) == 0 || len(b) == 0 {
+		return 0, true
+	}
+
+	best := func(from, to []core_v1.Container) float64 {
+		total := 0.0
+		for _, f := range from {
+			repo, tag := parseImage(f.Image)
+			match := 0.0
+			for _, t := range to {
+				if r, tg := parseImage(t.Image); r == repo && tg == tag {
+					match = 1
+				} else if r == repo {
+					match = math.Max(match, 0.7)
+				}
+			}
+			total += match
+		}
+		return total / float64(len(from))
+	}
+	return (best(a, b) + best(b, a)) / 2, true
+}
+
+// parseImage splits a container image into its repository and tag (or digest), which defaults to "latest".
+// i.e. "registry:5000/app:v1" is ("registry:5000/app", "v1")
+func parseImage(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// envVars returns the env vars set on the containers as "NAME=value", with references described instead of values.
+func envVars(containers []core_v1.Container) map[string]bool {
+	vars := make(map[string]bool)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			value := env.Value
+			if from := env.ValueFrom; from != nil {
+				switch {
+				case from.ConfigMapKeyRef != nil:
+					value = fmt.Sprintf("configmaps/%s/%s", from.ConfigMapKeyRef.Name, from.ConfigMapKeyRef.Key)
+				case from.SecretKeyRef != nil:
+					value = fmt.Sprintf("secrets/%s/%s", from.SecretKeyRef.Name, from.SecretKeyRef.Key)
+				case from.FieldRef != nil:
+					value = from.FieldRef.FieldPath
+				case from.ResourceFieldRef != nil:
+					value = from.ResourceFieldRef.Resource
+				}
+			}
+			vars[env.Name+"="+value] = true
+		}
+	}
+	return vars
+}
+
+// jaccard returns the size of the intersection of 'a' and 'b' over the size of their union.
+func jaccard(a, b map[string]bool) (float64, bool) {
+	union := len(b)
+	intersection := 0
+	for v := range a {
+		if b[v] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 0, false
+	}
+	return float64(intersection) / float64(union), true
+}
+
+// resourceRequests returns the total requests of the containers, per resource (i.e. cpu).
+func resourceRequests(containers []core_v1.Container) core_v1.ResourceList {
+	requests := core_v1.ResourceList{}
+	for _, container := range containers {
+		for name, q := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(q)
+			requests[name] = total
+		}
+	}
+	return requests
+}
+
+// requestsSimilarity averages the ratio of the smaller to the larger request of each resource requested by either.
+func requestsSimilarity(a, b core_v1.ResourceList) (float64, bool) {
+	names := make(map[core_v1.ResourceName]bool)
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+
+	if len(names) == 0 {
+		return 0, false
+	}
+
+	total := 0.0
+	for name := range names {
+		total += ratio(a[name], b[name])
+	}
+	return total / float64(len(names)), true
+}
+
+func ratio(a, b resource.Quantity) float64 {
+	if a.Cmp(b) == 0 {
+		return 1
+	}
+
+	x, y := float64(a.MilliValue()), float64(b.MilliValue())
+	return math.Min(x, y) / math.Max(x, y)
+}
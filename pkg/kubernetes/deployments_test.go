@@ -1,10 +1,95 @@
 package kubernetes
 
 import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+func TestSimilarity(t *testing.T) {
+	container := func(image string, env map[string]string, cpu string) v1.Container {
+		c := v1.Container{Name: "app", Image: image}
+		for name, value := range env {
+			c.Env = append(c.Env, v1.EnvVar{Name: name, Value: value})
+		}
+		if cpu != "" {
+			c.Resources.Requests = v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}
+		}
+		return c
+	}
+	deployment := func(instance string, containers ...v1.Container) appsv1.Deployment {
+		d := appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"instance": instance}}}
+		d.Spec.Template.Spec.Containers = containers
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		a, b     appsv1.Deployment
+		expected Similarity
+	}{
+		{
+			name:     "Only instances are compared without containers",
+			a:        deployment("dev"),
+			b:        deployment("dev2"),
+			expected: Similarity{Instance: "dev2", Score: 1, Scores: []Score{{"instance", 1}}},
+		},
+		{
+			name: "Identical containers with different instances",
+			a:    deployment("qa", container("app:v1", map[string]string{"A": "1"}, "100m")),
+			b:    deployment("dev", container("app:v1", map[string]string{"A": "1"}, "100m")),
+			expected: Similarity{Instance: "dev", Score: 0.6, Scores: []Score{
+				{"instance", 0}, {"image", 1}, {"env", 1}, {"requests", 1},
+			}},
+		},
+		{
+			name: "Images with different tags, overlapping env vars and requests",
+			a:    deployment("dev", container("registry:5000/app:v1", map[string]string{"A": "1", "B": "2"}, "100m")),
+			b:    deployment("dev", container("registry:5000/app:v2", map[string]string{"A": "1", "B": "3"}, "200m")),
+			expected: Similarity{Instance: "dev", Score: 0.4 + 0.3*0.7 + 0.2/3 + 0.1*0.5, Scores: []Score{
+				{"instance", 1}, {"image", 0.7}, {"env", 1.0 / 3}, {"requests", 0.5},
+			}},
+		},
+		{
+			name: "Missing containers don't match",
+			a:    deployment("dev", container("app", nil, "")),
+			b:    deployment("dev"),
+			expected: Similarity{Instance: "dev", Score: 0.4 / 0.7, Scores: []Score{
+				{"instance", 1}, {"image", 0},
+			}},
+		},
+	}
+
+	approx := cmp.Comparer(func(x, y float64) bool { return x-y < 1e-9 && y-x < 1e-9 })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := similarity(tt.a, tt.b, "instance")
+			if diff := cmp.Diff(actual, tt.expected, approx); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestParseImage(t *testing.T) {
+	for image, expected := range map[string][2]string{
+		"nginx":                       {"nginx", "latest"},
+		"nginx:1.21":                  {"nginx", "1.21"},
+		"registry:5000/team/app":      {"registry:5000/team/app", "latest"},
+		"registry:5000/team/app:v2":   {"registry:5000/team/app", "v2"},
+		"app@sha256:0123456789abcdef": {"app", "sha256:0123456789abcdef"},
+	} {
+		repo, tag := parseImage(image)
+		if repo != expected[0] || tag != expected[1] {
+			t.Errorf("expected %s to be %v, got [%s %s]", image, expected, repo, tag)
+		}
+	}
+}
+
 //
 //func TestListDuplicateDeployments(t *testing.T) {
 //	var tests = []struct {
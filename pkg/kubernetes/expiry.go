@@ -0,0 +1,40 @@
+package kubernetes
+
+import (
+	"fmt"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TTLAnnotation overrides the global age for an object with its own duration (i.e. "72h") since creation
+	TTLAnnotation = "karetaker.io/ttl"
+
+	// ExpiresAtAnnotation overrides the global age for an object with an absolute RFC3339 timestamp.
+	// It takes priority over TTLAnnotation when both are set.
+	ExpiresAtAnnotation = "karetaker.io/expires-at"
+)
+
+// expiry returns when the object created at 'created' expires, using its annotations or the default age 'd'.
+// An error is returned for invalid annotations, rather than falling back to 'd' and deleting it early.
+func expiry(obj meta_v1.Object, created time.Time, d time.Duration) (time.Time, error) {
+	annotations := obj.GetAnnotations()
+	if at, found := annotations[ExpiresAtAnnotation]; found {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s (invalid: %s)", ExpiresAtAnnotation, at)
+		}
+		return t, nil
+	}
+
+	if ttl, found := annotations[TTLAnnotation]; found {
+		t, err := time.ParseDuration(ttl)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s (invalid: %s)", TTLAnnotation, ttl)
+		}
+		return created.Add(t), nil
+	}
+
+	return created.Add(d), nil
+}
@@ -0,0 +1,93 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestExpiry(t *testing.T) {
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Time
+		wantErr     bool
+	}{
+		{
+			name: "Falls back to the default age",
+			want: created.Add(48 * time.Hour),
+		},
+		{
+			name:        "TTL overrides the default age",
+			annotations: map[string]string{TTLAnnotation: "2h"},
+			want:        created.Add(2 * time.Hour),
+		},
+		{
+			name:        "Expires-at overrides the default age and TTL",
+			annotations: map[string]string{TTLAnnotation: "2h", ExpiresAtAnnotation: "2021-06-10T00:00:00Z"},
+			want:        time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Returns an error for an invalid TTL",
+			annotations: map[string]string{TTLAnnotation: "3 days"},
+			wantErr:     true,
+		},
+		{
+			name:        "Returns an error for an invalid timestamp",
+			annotations: map[string]string{ExpiresAtAnnotation: "tomorrow"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newDeploymentWithTime("deploy", created)
+			obj.SetAnnotations(tt.annotations)
+
+			got, err := expiry(obj, created, 48*time.Hour)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expiry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expiry() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourcesOlderThanExpiry(t *testing.T) {
+	created := time.Now().Add(-8 * time.Hour).UTC().Truncate(time.Second)
+	expiresAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	shortTTL := newDeploymentWithTime("short-ttl", created)
+	shortTTL.SetAnnotations(map[string]string{TTLAnnotation: "2h"})
+	longTTL := newDeploymentWithTime("long-ttl", created)
+	longTTL.SetAnnotations(map[string]string{TTLAnnotation: "72h"})
+	expired := newDeploymentWithTime("expires-at", created)
+	expired.SetAnnotations(map[string]string{ExpiresAtAnnotation: expiresAt.Format(time.RFC3339)})
+	invalid := newDeploymentWithTime("invalid", created)
+	invalid.SetAnnotations(map[string]string{TTLAnnotation: "soon"})
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		shortTTL, longTTL, expired, invalid, newDeploymentWithTime("default-age", created))
+
+	actual, err := ResourcesOlderThan(client, deployResource, "default", 24*time.Hour, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []Resource{
+		{Name: "short-ttl", Kind: "deployments", Age: 8 * time.Hour, Expires: created.Add(2 * time.Hour)},
+		{Name: "expires-at", Kind: "deployments", Age: 8 * time.Hour, Expires: expiresAt},
+		{Name: "invalid", Kind: "deployments", Age: 8 * time.Hour, Protected: "karetaker.io/ttl (invalid: soon)"},
+	}
+	if diff := cmp.Diff(actual, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
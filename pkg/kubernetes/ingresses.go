@@ -0,0 +1,90 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// OrphanedIngresses returns the Ingresses of type 'r' (i.e. networking.k8s.io/v1beta1 on older clusters) in the
+// namespace 'n' whose backend Services no longer exist.
+// An Ingress is only orphaned when all of its backend Services are missing, as otherwise it still routes to the others.
+// Ingresses with only resource backends (i.e. a storage bucket) are never orphaned.
+func OrphanedIngresses(c dynamic.Interface, r schema.GroupVersionResource, n string, a []string) ([]Resource, error) {
+	list, err := c.Resource(r).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := existenceCache(c)
+	var resource []Resource
+	for _, ingress := range list.Items {
+		if stringContainsArrayElement(ingress.GetName(), a) {
+			continue
+		}
+
+		var refs []objectRef
+		for _, svc := range ingressBackends(ingress) {
+			refs = append(refs, objectRef{ServiceSchema, ingress.GetNamespace(), svc})
+		}
+
+		missing, err := missingRefs(exists, refs)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(refs) > 0 && len(missing) == len(refs) {
+			orphan, err := orphanedResource(ingress, r, nsProtection, now, missing)
+			if err != nil {
+				return nil, err
+			}
+			resource = append(resource, orphan)
+		}
+	}
+
+	return resource, nil
+}
+
+// ingressBackends returns the unique names of the Services an Ingress routes to, from its default backend and rules.
+// Both networking.k8s.io/v1 ('service.name') and v1beta1 ('serviceName') backends are supported.
+func ingressBackends(ingress unstructured.Unstructured) []string {
+	var backends []map[string]interface{}
+	for _, fields := range [][]string{{"spec", "defaultBackend"}, {"spec", "backend"}} {
+		if backend, found, _ := unstructured.NestedMap(ingress.Object, fields...); found {
+			backends = append(backends, backend)
+		}
+	}
+
+	rules, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+	for _, rule := range rules {
+		paths, _, _ := unstructured.NestedSlice(rule.(map[string]interface{}), "http", "paths")
+		for _, path := range paths {
+			if backend, found, _ := unstructured.NestedMap(path.(map[string]interface{}), "backend"); found {
+				backends = append(backends, backend)
+			}
+		}
+	}
+
+	var services []string
+	for _, backend := range backends {
+		name, _, _ := unstructured.NestedString(backend, "service", "name")
+		if name == "" {
+			name, _, _ = unstructured.NestedString(backend, "serviceName")
+		}
+		if name != "" && !stringInSlice(name, services) {
+			services = append(services, name)
+		}
+	}
+	return services
+}
@@ -0,0 +1,64 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestOrphanedIngresses(t *testing.T) {
+	rule := func(services ...string) map[string]interface{} {
+		var paths []interface{}
+		for _, svc := range services {
+			paths = append(paths, map[string]interface{}{
+				"path":    "/" + svc,
+				"backend": map[string]interface{}{"service": map[string]interface{}{"name": svc}},
+			})
+		}
+		return map[string]interface{}{"http": map[string]interface{}{"paths": paths}}
+	}
+
+	web := newResource("networking.k8s.io/v1", "Ingress", "web")
+	web.Object["spec"] = map[string]interface{}{"rules": []interface{}{rule("web", "removed")}}
+
+	removed := newResource("networking.k8s.io/v1", "Ingress", "removed")
+	removed.Object["spec"] = map[string]interface{}{
+		"defaultBackend": map[string]interface{}{"service": map[string]interface{}{"name": "default-backend"}},
+		"rules":          []interface{}{rule("removed", "removed-api")},
+	}
+
+	bucket := newResource("networking.k8s.io/v1", "Ingress", "bucket")
+	bucket.Object["spec"] = map[string]interface{}{
+		"defaultBackend": map[string]interface{}{"resource": map[string]interface{}{"kind": "StorageBucket", "name": "assets"}},
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), web, removed, bucket, newResource("v1", "Service", "web"))
+	ingresses, err := OrphanedIngresses(client, IngressSchema, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"removed": {"services/default/default-backend", "services/default/removed", "services/default/removed-api"},
+	}
+	if diff := cmp.Diff(missing(ingresses), expected); diff != "" {
+		t.Errorf("OrphanedIngresses() differ (-got, +want): %s", diff)
+	}
+}
+
+func TestIngressBackendsV1Beta1(t *testing.T) {
+	ingress := newResource("networking.k8s.io/v1beta1", "Ingress", "legacy")
+	ingress.Object["spec"] = map[string]interface{}{
+		"backend": map[string]interface{}{"serviceName": "default-backend", "servicePort": int64(80)},
+		"rules": []interface{}{map[string]interface{}{"http": map[string]interface{}{"paths": []interface{}{
+			map[string]interface{}{"backend": map[string]interface{}{"serviceName": "legacy", "servicePort": int64(80)}},
+			map[string]interface{}{"backend": map[string]interface{}{"serviceName": "default-backend", "servicePort": int64(80)}},
+		}}}},
+	}
+
+	if diff := cmp.Diff(ingressBackends(*ingress), []string{"default-backend", "legacy"}); diff != "" {
+		t.Errorf("ingressBackends() differ (-got, +want): %s", diff)
+	}
+}
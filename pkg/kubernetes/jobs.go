@@ -2,6 +2,7 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -9,20 +10,22 @@ import (
 	"time"
 )
 
-//
+// JobsNotRunning returns the Jobs in the namespace 'n' which have completed or failed (see objectStatus).
+// Their age is the time since they finished, rather than since they were created.
 func JobsNotRunning(c dynamic.Interface, n string, a []string) ([]Resource, error) {
 	list, err := c.Resource(JobSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
 	if err != nil {
 		return nil, errors.Wrap(err, "getting resource")
 	}
 
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
 	var resource []Resource
 	for _, job := range list.Items {
-		age, err := objectAge(job)
-		if err != nil {
-			return nil, err
-		}
-
 		name, found, err := unstructured.NestedString(job.Object, "metadata", "name")
 		if err != nil || !found {
 			return nil, err
@@ -34,11 +37,17 @@ func JobsNotRunning(c dynamic.Interface, n string, a []string) ([]Resource, erro
 		}
 
 		if !stringContainsArrayElement(name, a) && (status == Completed || status == Failed) {
+			finished, err := jobFinishedTime(job, status)
+			if err != nil {
+				return nil, err
+			}
+
 			resource = append(resource, Resource{
-				Name:   name,
-				Kind:   JobSchema.Resource,
-				Age:    age.Round(time.Minute),
-				Status: status,
+				Name:      name,
+				Kind:      JobSchema.Resource,
+				Age:       now.Sub(finished).Round(time.Minute),
+				Status:    status,
+				Protected: objectProtection(&job, nsProtection, now),
 			})
 		}
 	}
@@ -46,27 +55,89 @@ func JobsNotRunning(c dynamic.Interface, n string, a []string) ([]Resource, erro
 	return resource, nil
 }
 
-// TODO: Cover deploy status also
+// objectStatus returns the state of the Job from its 'Complete' or 'Failed' condition, otherwise it's 'Running'
+// while it has active pods. Jobs without conditions (i.e. on older clusters) are 'Completed' once 'status.succeeded'
+// reaches 'spec.completions', as their failures can't be told apart from retries.
 func objectStatus(job unstructured.Unstructured) (Status, error) {
-	succeeded, _, err := unstructured.NestedInt64(job.Object, "status", "succeeded")
+	if complete, _, err := jobCondition(job, "Complete"); err != nil || complete {
+		return Completed, err
+	}
+	if failed, _, err := jobCondition(job, "Failed"); err != nil || failed {
+		return Failed, err
+	}
+
+	active, _, err := unstructured.NestedInt64(job.Object, "status", "active")
 	if err != nil {
 		return Unknown, err
-	} else if succeeded == 1 {
-		return Completed, nil
+	} else if active > 0 {
+		return Running, nil
 	}
 
-	failed, _, err := unstructured.NestedInt64(job.Object, "status", "failed")
+	succeeded, _, err := unstructured.NestedInt64(job.Object, "status", "succeeded")
 	if err != nil {
 		return Unknown, err
-	} else if failed == 1 {
-		return Failed, nil
 	}
 
-	running, _, err := unstructured.NestedInt64(job.Object, "status", "running")
+	// without completions, the Job is done once any pod succeeds
+	completions, found, err := unstructured.NestedInt64(job.Object, "spec", "completions")
 	if err != nil {
 		return Unknown, err
-	} else if running == 1 {
-		return Running, nil
+	} else if !found {
+		completions = 1
 	}
-	return Unknown, err
+
+	if succeeded > 0 && succeeded >= completions {
+		return Completed, nil
+	}
+	return Unknown, nil
+}
+
+// jobCondition returns if the Job's condition of type 't' (i.e. "Complete") is true, and when it last transitioned.
+func jobCondition(job unstructured.Unstructured, t string) (bool, time.Time, error) {
+	conditions, _, err := unstructured.NestedSlice(job.Object, "status", "conditions")
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		conditionType, _, _ := unstructured.NestedString(condition, "type")
+		status, _, _ := unstructured.NestedString(condition, "status")
+		if conditionType != t || status != "True" {
+			continue
+		}
+
+		transitioned, found, _ := unstructured.NestedString(condition, "lastTransitionTime")
+		if !found {
+			return true, time.Time{}, nil
+		}
+		ts, err := time.Parse(time.RFC3339, transitioned)
+		if err != nil {
+			return true, time.Time{}, fmt.Errorf("unable to parse 'lastTransitionTime' of %s: %s", job.GetName(), err)
+		}
+		return true, ts, nil
+	}
+	return false, time.Time{}, nil
+}
+
+// jobFinishedTime returns when the Job finished with 'status', from its 'status.completionTime' or the transition
+// of its 'Complete' or 'Failed' condition, falling back to its creation when neither is set.
+func jobFinishedTime(job unstructured.Unstructured, status Status) (time.Time, error) {
+	if completion, found, _ := unstructured.NestedString(job.Object, "status", "completionTime"); found && status == Completed {
+		t, err := time.Parse(time.RFC3339, completion)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to parse 'completionTime' of %s: %s", job.GetName(), err)
+		}
+		return t, nil
+	}
+
+	condition := "Complete"
+	if status == Failed {
+		condition = "Failed"
+	}
+	if _, t, err := jobCondition(job, condition); err != nil || !t.IsZero() {
+		return t, err
+	}
+
+	return objectCreation(job)
 }
@@ -2,6 +2,7 @@ package kubernetes
 
 import (
 	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
@@ -33,6 +34,25 @@ func TestJobsNotRunning(t *testing.T) {
 				{Name: "failed-job", Kind: "jobs", Age: 0, Status: Failed},
 			},
 		},
+		{
+			name:  "Age is the time since the Job finished",
+			allow: []string{},
+			client: fake.NewSimpleDynamicClient(scheme,
+				newJobWithStatusAndTime("completed-job", map[string]interface{}{
+					"succeeded":      int64(1),
+					"completionTime": time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+					"conditions":     []interface{}{newJobCondition("Complete", time.Now().Add(-2*time.Hour))},
+				}, time.Now().Add(-48*time.Hour)),
+				newJobWithStatusAndTime("failed-job", map[string]interface{}{
+					"failed":     int64(7),
+					"conditions": []interface{}{newJobCondition("Failed", time.Now().Add(-3*time.Hour))},
+				}, time.Now().Add(-48*time.Hour)),
+			),
+			expected: []Resource{
+				{Name: "completed-job", Kind: "jobs", Age: 2 * time.Hour, Status: Completed},
+				{Name: "failed-job", Kind: "jobs", Age: 3 * time.Hour, Status: Failed},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -76,6 +96,35 @@ func TestObjectStatus(t *testing.T) {
 			want: Running,
 			wantErr: false,
 		},
+		{
+			name: "Returns 'Running' for parallel Jobs with completions remaining",
+			args: *newJobWithCompletions("job", 5, map[string]interface{}{"succeeded": int64(3), "active": int64(2)}),
+			want: Running,
+		},
+		{
+			name: "Returns 'Running' for Jobs retrying after a failure",
+			args: *newJobWithCompletions("job", 1, map[string]interface{}{"failed": int64(2), "active": int64(1)}),
+			want: Running,
+		},
+		{
+			name: "Returns 'Completed' for parallel Jobs from their condition",
+			args: *newJobWithCompletions("job", 5, map[string]interface{}{
+				"succeeded":  int64(5),
+				"failed":     int64(2),
+				"conditions": []interface{}{newJobCondition("Complete", time.Now())},
+			}),
+			want: Completed,
+		},
+		{
+			name: "Returns 'Completed' without conditions once all completions succeeded",
+			args: *newJobWithCompletions("job", 5, map[string]interface{}{"succeeded": int64(5)}),
+			want: Completed,
+		},
+		{
+			name: "Returns 'Unknown' for failures without a condition",
+			args: *newJobWithCompletions("job", 1, map[string]interface{}{"failed": int64(3)}),
+			want: Unknown,
+		},
 		{
 			name: "Returns 'Unknown' as expected",
 			args: *newInvalidJob("job"),
@@ -99,19 +148,21 @@ func TestObjectStatus(t *testing.T) {
 
 func newCompletedJob(name string) *unstructured.Unstructured {
 	return newJobWithStatus(name, map[string]interface{}{
-		"succeeded": int64(1),
+		"succeeded":  int64(1),
+		"conditions": []interface{}{newJobCondition("Complete", time.Now())},
 	})
 }
 
 func newRunningJob(name string) *unstructured.Unstructured {
 	return newJobWithStatus(name, map[string]interface{}{
-		"running": int64(1),
+		"active": int64(1),
 	})
 }
 
 func newFailedJob(name string) *unstructured.Unstructured {
 	return newJobWithStatus(name, map[string]interface{}{
-		"failed": int64(1),
+		"failed":     int64(1),
+		"conditions": []interface{}{newJobCondition("Failed", time.Now())},
 	})
 }
 
@@ -137,6 +188,18 @@ func newJobWithStatus(name string, status map[string]interface{}) *unstructured.
 	}
 }
 
+func newJobWithStatusAndTime(name string, status map[string]interface{}, t time.Time) *unstructured.Unstructured {
+	job := newJobWithStatus(name, status)
+	job.SetCreationTimestamp(meta_v1.NewTime(t))
+	return job
+}
+
+func newJobWithCompletions(name string, completions int64, status map[string]interface{}) *unstructured.Unstructured {
+	job := newJobWithStatus(name, status)
+	job.Object["spec"] = map[string]interface{}{"completions": completions, "backoffLimit": int64(6)}
+	return job
+}
+
 func newInvalidJob(name string) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -149,4 +212,7 @@ func newInvalidJob(name string) *unstructured.Unstructured {
 			},
 		},
 	}
-}
\ No newline at end of file
+}
+func newJobCondition(t string, transitioned time.Time) map[string]interface{} {
+	return map[string]interface{}{"type": t, "status": "True", "lastTransitionTime": transitioned.Format(time.RFC3339)}
+}
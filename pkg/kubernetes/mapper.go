@@ -0,0 +1,76 @@
+package kubernetes
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+)
+
+// aliases are resource shorthands supported before discovery was used, which the API server doesn't advertise.
+var aliases = map[string]string{
+	"ss": "statefulsets",
+}
+
+// RESTMapper returns a RESTMapper backed by the API server's discovery data depending on the kubeconfig source.
+func RESTMapper(o ClientOptions) (meta.RESTMapper, error) {
+	config, err := RestConfig(o)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRESTMapper(d), nil
+}
+
+// NewRESTMapper returns a RESTMapper for the discovery client 'd', which expands short names (i.e. "deploy").
+// Discovery is deferred until the first lookup and cached for the lifetime of the mapper.
+func NewRESTMapper(d discovery.DiscoveryInterface) meta.RESTMapper {
+	cached := memory.NewMemCacheClient(d)
+	return restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached)
+}
+
+// ResolveResource maps a resource argument to its resource type the same way kubectl does.
+// The argument can be a kind, singular, plural or short name, optionally qualified with a version and/or group,
+// i.e. "Deployment", "deploy", "deployments.apps" or "rollouts.v1alpha1.argoproj.io".
+func ResolveResource(m meta.RESTMapper, r string) (*meta.RESTMapping, error) {
+	if alias, found := aliases[r]; found {
+		r = alias
+	}
+
+	gvr, gr := schema.ParseResourceArg(r)
+	gvk := schema.GroupVersionKind{}
+	if gvr != nil {
+		gvk, _ = m.KindFor(*gvr)
+	}
+	if gvk.Empty() {
+		gvk, _ = m.KindFor(gr.WithVersion(""))
+	}
+	if !gvk.Empty() {
+		return m.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+
+	fullGVK, gk := schema.ParseKindArg(r)
+	if fullGVK != nil {
+		if mapping, err := m.RESTMapping(fullGVK.GroupKind(), fullGVK.Version); err == nil {
+			return mapping, nil
+		}
+	}
+
+	mapping, err := m.RESTMapping(gk)
+	if meta.IsNoMatchError(err) {
+		return nil, errors.Errorf("the server doesn't have a resource type %q", r)
+	}
+	return mapping, err
+}
+
+// IsNamespaced returns if the resource type of the mapping 'm' is namespaced, rather than cluster-scoped.
+func IsNamespaced(m *meta.RESTMapping) bool {
+	return m.Scope.Name() == meta.RESTScopeNameNamespace
+}
@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var discoveryResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", SingularName: "configmap", Kind: "ConfigMap", Namespaced: true, ShortNames: []string{"cm"}},
+			{Name: "persistentvolumes", SingularName: "persistentvolume", Kind: "PersistentVolume", ShortNames: []string{"pv"}},
+		},
+	},
+	{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", SingularName: "deployment", Kind: "Deployment", Namespaced: true, ShortNames: []string{"deploy"}},
+			{Name: "statefulsets", SingularName: "statefulset", Kind: "StatefulSet", Namespaced: true, ShortNames: []string{"sts"}},
+		},
+	},
+	{
+		GroupVersion: "argoproj.io/v1alpha1",
+		APIResources: []metav1.APIResource{
+			{Name: "rollouts", SingularName: "rollout", Kind: "Rollout", Namespaced: true, ShortNames: []string{"ro"}},
+		},
+	},
+}
+
+func TestResolveResource(t *testing.T) {
+	tests := []struct {
+		name           string
+		resource       string
+		want           schema.GroupVersionResource
+		wantNamespaced bool
+		wantErr        bool
+	}{
+		{
+			name:           "Resolves plural names",
+			resource:       "deployments",
+			want:           DeploymentSchema,
+			wantNamespaced: true,
+		},
+		{
+			name:           "Resolves singular names",
+			resource:       "configmap",
+			want:           ConfigMapSchema,
+			wantNamespaced: true,
+		},
+		{
+			name:           "Resolves kinds",
+			resource:       "Deployment",
+			want:           DeploymentSchema,
+			wantNamespaced: true,
+		},
+		{
+			name:           "Resolves short names",
+			resource:       "deploy",
+			want:           DeploymentSchema,
+			wantNamespaced: true,
+		},
+		{
+			name:           "Resolves legacy aliases",
+			resource:       "ss",
+			want:           StatefulSetSchema,
+			wantNamespaced: true,
+		},
+		{
+			name:           "Resolves group qualified custom resources",
+			resource:       "rollouts.argoproj.io",
+			want:           schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"},
+			wantNamespaced: true,
+		},
+		{
+			name:     "Resolves cluster-scoped resources",
+			resource: "pv",
+			want:     schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"},
+		},
+		{
+			name:     "Returns an error for unknown resources",
+			resource: "virtualservices.networking.istio.io",
+			wantErr:  true,
+		},
+	}
+
+	m := NewRESTMapper(&fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: discoveryResources}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveResource(m, tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveResource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			if got.Resource != tt.want {
+				t.Errorf("ResolveResource() got = %v, want %v", got.Resource, tt.want)
+			}
+			if IsNamespaced(got) != tt.wantNamespaced {
+				t.Errorf("IsNamespaced() got = %v, want %v", IsNamespaced(got), tt.wantNamespaced)
+			}
+		})
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"github.com/pkg/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
+// SystemNamespaces are excluded by default when operating across multiple namespaces
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
 // ListNamespaces returns a list of the namespaces in the current cluster
 func ListNamespaces(clientset kubernetes.Interface) ([]string, error) {
 	list, err := clientset.CoreV1().Namespaces().List(context.TODO(), meta_v1.ListOptions{})
@@ -21,3 +25,26 @@ func ListNamespaces(clientset kubernetes.Interface) ([]string, error) {
 
 	return namespaces, nil
 }
+
+// SelectNamespaces returns the namespaces matching the label selector 's' (or all namespaces when empty).
+// Namespaces named in the exclude list 'e' are skipped.
+func SelectNamespaces(c dynamic.Interface, s string, e []string) ([]string, error) {
+	list, err := c.Resource(NamespaceSchema).List(context.TODO(), meta_v1.ListOptions{LabelSelector: s})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting namespaces")
+	}
+
+	excluded := make(map[string]bool)
+	for _, namespace := range e {
+		excluded[namespace] = true
+	}
+
+	namespaces := []string{}
+	for _, namespace := range list.Items {
+		if !excluded[namespace.GetName()] {
+			namespaces = append(namespaces, namespace.GetName())
+		}
+	}
+
+	return namespaces, nil
+}
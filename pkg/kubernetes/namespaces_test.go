@@ -9,6 +9,9 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -49,3 +52,65 @@ func TestListImages(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	client := dynamicfake.NewSimpleDynamicClient(scheme,
+		newNamespace("default", nil),
+		newNamespace("kube-system", nil),
+		newNamespace("team-a", map[string]interface{}{"env": "dev"}),
+		newNamespace("team-b", map[string]interface{}{"env": "dev"}),
+		newNamespace("team-c", map[string]interface{}{"env": "prod"}),
+	)
+
+	tests := []struct {
+		name     string
+		selector string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "Returns all namespaces without system namespaces",
+			exclude:  SystemNamespaces,
+			expected: []string{"default", "team-a", "team-b", "team-c"},
+		},
+		{
+			name:     "Returns namespaces matching the label selector",
+			selector: "env=dev",
+			expected: []string{"team-a", "team-b"},
+		},
+		{
+			name:     "Returns namespaces matching the label selector without excluded namespaces",
+			selector: "env",
+			exclude:  []string{"team-b"},
+			expected: []string{"team-a", "team-c"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := SelectNamespaces(client, test.selector, test.exclude)
+			if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+			if diff := cmp.Diff(actual, test.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expected, diff)
+				return
+			}
+		})
+	}
+}
+
+func newNamespace(name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": labels,
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// objectRef is an object referenced by another, which may no longer exist.
+type objectRef struct {
+	resource  schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+// String formats the reference as "resource/namespace/name", or "resource/name" for cluster-scoped objects.
+func (r objectRef) String() string {
+	if r.namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", r.resource.Resource, r.namespace, r.name)
+	}
+	return fmt.Sprintf("%s/%s", r.resource.Resource, r.name)
+}
+
+// existenceCache returns a func checking if a referenced object exists.
+// Results are cached, as objects are often referenced more than once (i.e. a ClusterRole by many bindings).
+func existenceCache(c dynamic.Interface) func(r objectRef) (bool, error) {
+	cache := make(map[string]bool)
+	return func(r objectRef) (bool, error) {
+		key := r.String()
+		if found, cached := cache[key]; cached {
+			return found, nil
+		}
+
+		_, err := c.Resource(r.resource).Namespace(r.namespace).Get(context.TODO(), r.name, meta_v1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "getting %s", key)
+		}
+
+		cache[key] = err == nil
+		return err == nil, nil
+	}
+}
+
+// missingRefs returns the references in 'refs' which no longer exist.
+func missingRefs(exists func(r objectRef) (bool, error), refs []objectRef) ([]string, error) {
+	var missing []string
+	for _, ref := range refs {
+		found, err := exists(ref)
+		if err != nil {
+			return nil, err
+		} else if !found {
+			missing = append(missing, ref.String())
+		}
+	}
+	return missing, nil
+}
+
+// orphanedResource returns the object 'obj' of type 'r' as a Resource, alongside the objects it references which are 'missing'.
+func orphanedResource(obj unstructured.Unstructured, r schema.GroupVersionResource, nsProtection string, now time.Time, missing []string) (Resource, error) {
+	age, err := objectAge(obj)
+	if err != nil {
+		return Resource{}, err
+	}
+
+	return Resource{
+		Name:      obj.GetName(),
+		Kind:      r.Resource,
+		Age:       age.Round(time.Minute),
+		Protected: objectProtection(&obj, nsProtection, now),
+		Missing:   missing,
+	}, nil
+}
@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// FinishedPods returns the pods in the namespace 'n' which have terminated and won't be restarted:
+// those in the 'Failed' or 'Succeeded' phase, including evicted pods. Their status is why they terminated (see podStatus).
+// Pods owned by a Job which is still active are excluded, as the Job controller relies on them to track its progress.
+func FinishedPods(c dynamic.Interface, n string, a []string) ([]Resource, error) {
+	list, err := c.Resource(PodSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	activeJobs := make(map[string]bool)
+	var resource []Resource
+	for _, pod := range list.Items {
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		if stringContainsArrayElement(pod.GetName(), a) || (phase != string(core_v1.PodFailed) && phase != string(core_v1.PodSucceeded)) {
+			continue
+		}
+
+		if job := meta_v1.GetControllerOf(&pod); job != nil && job.Kind == "Job" {
+			active, cached := activeJobs[job.Name]
+			if !cached {
+				active, err = jobActive(c, n, job.Name)
+				if err != nil {
+					return nil, err
+				}
+				activeJobs[job.Name] = active
+			}
+			if active {
+				continue
+			}
+		}
+
+		age, err := objectAge(pod)
+		if err != nil {
+			return nil, err
+		}
+
+		resource = append(resource, Resource{
+			Name:      pod.GetName(),
+			Kind:      PodSchema.Resource,
+			Age:       age.Round(time.Minute),
+			Status:    podStatus(pod),
+			Protected: objectProtection(&pod, nsProtection, now),
+		})
+	}
+
+	return resource, nil
+}
+
+// podStatus returns why a terminated pod finished, preferring the pod's reason (i.e. "Evicted"),
+// then the reason its first terminated container finished (i.e. "Completed", "Error", "OOMKilled" or "ContainerStatusUnknown"),
+// falling back to its phase. Init containers are only considered when they didn't complete successfully.
+func podStatus(pod unstructured.Unstructured) Status {
+	if reason, _, _ := unstructured.NestedString(pod.Object, "status", "reason"); reason != "" {
+		return Status(reason)
+	}
+
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+		for _, s := range statuses {
+			reason, _, _ := unstructured.NestedString(s.(map[string]interface{}), "state", "terminated", "reason")
+			if reason != "" && (field == "containerStatuses" || reason != string(Completed)) {
+				return Status(reason)
+			}
+		}
+	}
+
+	phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+	return Status(phase)
+}
+
+// jobActive returns if the Job 'name' in the namespace 'n' exists and hasn't completed or failed.
+func jobActive(c dynamic.Interface, n, name string) (bool, error) {
+	job, err := c.Resource(JobSchema).Namespace(n).Get(context.TODO(), name, meta_v1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "getting job %s", name)
+	}
+
+	status, err := objectStatus(*job)
+	if err != nil {
+		return false, err
+	}
+	return status != Completed && status != Failed, nil
+}
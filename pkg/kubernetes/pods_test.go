@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestFinishedPods(t *testing.T) {
+	terminated := func(reason string) map[string]interface{} {
+		return map[string]interface{}{"state": map[string]interface{}{"terminated": map[string]interface{}{"reason": reason}}}
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newRunningJob("running-job"),
+		newCompletedJob("completed-job"),
+		newPod("web", map[string]interface{}{"phase": "Running"}, ""),
+		newPod("evicted", map[string]interface{}{"phase": "Failed", "reason": "Evicted"}, ""),
+		newPod("oom", map[string]interface{}{"phase": "Failed", "containerStatuses": []interface{}{terminated("OOMKilled")}}, ""),
+		newPod("init-error", map[string]interface{}{"phase": "Failed", "initContainerStatuses": []interface{}{terminated("Error")}}, ""),
+		newPod("migrate", map[string]interface{}{
+			"phase":                 "Succeeded",
+			"initContainerStatuses": []interface{}{terminated("Completed")},
+			"containerStatuses":     []interface{}{terminated("Completed")},
+		}, ""),
+		newPod("lost", map[string]interface{}{"phase": "Failed"}, ""),
+		newPod("running-job-x2v9", map[string]interface{}{"phase": "Failed", "containerStatuses": []interface{}{terminated("Error")}}, "running-job"),
+		newPod("completed-job-b7k1", map[string]interface{}{"phase": "Succeeded", "containerStatuses": []interface{}{terminated("Completed")}}, "completed-job"),
+		newPod("removed-job-k2m4", map[string]interface{}{"phase": "Failed", "containerStatuses": []interface{}{terminated("ContainerStatusUnknown")}}, "removed-job"),
+	)
+
+	pods, err := FinishedPods(client, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Resource{
+		{Name: "evicted", Kind: "pods", Status: "Evicted"},
+		{Name: "oom", Kind: "pods", Status: "OOMKilled"},
+		{Name: "init-error", Kind: "pods", Status: "Error"},
+		{Name: "migrate", Kind: "pods", Status: Completed},
+		{Name: "lost", Kind: "pods", Status: Failed},
+		{Name: "completed-job-b7k1", Kind: "pods", Status: Completed},
+		{Name: "removed-job-k2m4", Kind: "pods", Status: "ContainerStatusUnknown"},
+	}
+	if diff := cmp.Diff(pods, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func newPod(name string, status map[string]interface{}, job string) *unstructured.Unstructured {
+	pod := newResource("v1", "Pod", name)
+	pod.Object["status"] = status
+	if job != "" {
+		controller := true
+		pod.SetOwnerReferences([]meta_v1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: job, Controller: &controller}})
+	}
+	return pod
+}
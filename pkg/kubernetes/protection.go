@@ -0,0 +1,59 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// KeepAnnotation exempts an object (or all objects in a namespace) from clean-up when "true".
+	// It can also be set as a label, allowing protected objects to be selected.
+	KeepAnnotation = "karetaker.io/keep"
+
+	// KeepUntilAnnotation exempts an object (or all objects in a namespace) from clean-up until an RFC3339 timestamp
+	KeepUntilAnnotation = "karetaker.io/keep-until"
+)
+
+// protection returns why the object is exempt from clean-up at the time 'now', or empty when it isn't.
+// Invalid 'keep-until' timestamps are treated as protected, as the intention was to keep the object.
+func protection(obj meta_v1.Object, now time.Time) string {
+	if obj.GetAnnotations()[KeepAnnotation] == "true" || obj.GetLabels()[KeepAnnotation] == "true" {
+		return KeepAnnotation
+	}
+
+	if until, found := obj.GetAnnotations()[KeepUntilAnnotation]; found {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return fmt.Sprintf("%s (invalid: %s)", KeepUntilAnnotation, until)
+		} else if now.Before(t) {
+			return fmt.Sprintf("%s %s", KeepUntilAnnotation, until)
+		}
+	}
+
+	return ""
+}
+
+// namespaceProtection returns why all objects in the namespace 'n' are exempt from clean-up, or empty when they aren't.
+func namespaceProtection(c dynamic.Interface, n string, now time.Time) (string, error) {
+	if n == "" {
+		return "", nil
+	}
+
+	namespace, err := c.Resource(NamespaceSchema).Get(context.TODO(), n, meta_v1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "getting namespace")
+	}
+
+	if p := protection(namespace, now); p != "" {
+		return fmt.Sprintf("namespace %s", p), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,125 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestProtection(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		labels      map[string]string
+		want        string
+	}{
+		{
+			name: "Unprotected without annotations",
+			want: "",
+		},
+		{
+			name:        "Protected by the keep annotation",
+			annotations: map[string]string{KeepAnnotation: "true"},
+			want:        KeepAnnotation,
+		},
+		{
+			name:   "Protected by the keep label",
+			labels: map[string]string{KeepAnnotation: "true"},
+			want:   KeepAnnotation,
+		},
+		{
+			name:        "Unprotected when keep isn't 'true'",
+			annotations: map[string]string{KeepAnnotation: "false"},
+			want:        "",
+		},
+		{
+			name:        "Protected until a future timestamp",
+			annotations: map[string]string{KeepUntilAnnotation: "2021-07-01T00:00:00Z"},
+			want:        "karetaker.io/keep-until 2021-07-01T00:00:00Z",
+		},
+		{
+			name:        "Unprotected after the timestamp has passed",
+			annotations: map[string]string{KeepUntilAnnotation: "2021-05-01T00:00:00Z"},
+			want:        "",
+		},
+		{
+			name:        "Protected by an invalid timestamp",
+			annotations: map[string]string{KeepUntilAnnotation: "next week"},
+			want:        "karetaker.io/keep-until (invalid: next week)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newConfigmap("config")
+			obj.SetAnnotations(tt.annotations)
+			obj.SetLabels(tt.labels)
+
+			if got := protection(obj, now); got != tt.want {
+				t.Errorf("protection() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourcesOlderThanProtection(t *testing.T) {
+	old := time.Now().Add(-8 * time.Hour)
+	kept := newDeploymentWithTime("kept", old)
+	kept.SetAnnotations(map[string]string{KeepAnnotation: "true"})
+	expired := newDeploymentWithTime("expired", old)
+	expired.SetAnnotations(map[string]string{KeepUntilAnnotation: time.Now().Add(-time.Hour).Format(time.RFC3339)})
+
+	tests := []struct {
+		name      string
+		namespace *unstructured.Unstructured
+		expected  []Resource
+	}{
+		{
+			name:      "Protects annotated objects",
+			namespace: newProtectedNamespace("default", nil),
+			expected: []Resource{
+				{Name: "expired", Kind: "deployments", Age: 8 * time.Hour},
+				{Name: "kept", Kind: "deployments", Age: 8 * time.Hour, Protected: KeepAnnotation},
+			},
+		},
+		{
+			name:      "Protects all objects in an annotated namespace",
+			namespace: newProtectedNamespace("default", map[string]string{KeepAnnotation: "true"}),
+			expected: []Resource{
+				{Name: "expired", Kind: "deployments", Age: 8 * time.Hour, Protected: "namespace karetaker.io/keep"},
+				{Name: "kept", Kind: "deployments", Age: 8 * time.Hour, Protected: "namespace karetaker.io/keep"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(runtime.NewScheme(), tt.namespace, expired.DeepCopy(), kept.DeepCopy())
+
+			actual, err := ResourcesOlderThan(client, deployResource, "default", time.Hour, nil)
+			if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+			if diff := cmp.Diff(actual, tt.expected, cmpopts.IgnoreFields(Resource{}, "Expires")); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func newProtectedNamespace(name string, annotations map[string]string) *unstructured.Unstructured {
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(name)
+	ns.SetAnnotations(annotations)
+	return ns
+}
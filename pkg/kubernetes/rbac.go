@@ -0,0 +1,121 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// OrphanedRoleBindings returns the RoleBindings in the namespace 'n' whose roleRef or subjects no longer exist.
+func OrphanedRoleBindings(c dynamic.Interface, n string, a []string) ([]Resource, error) {
+	return orphanedBindings(c, RoleBindingSchema, n, a)
+}
+
+// OrphanedClusterRoleBindings returns the ClusterRoleBindings whose roleRef or subjects no longer exist.
+func OrphanedClusterRoleBindings(c dynamic.Interface, a []string) ([]Resource, error) {
+	return orphanedBindings(c, ClusterRoleBindingSchema, "", a)
+}
+
+// orphanedBindings returns the bindings of type 'r' which are orphaned, with the missing objects they reference.
+// A binding is orphaned when its Role (or ClusterRole) is missing, or when all of its subjects are missing ServiceAccounts.
+// Bindings with a missing subject alongside other subjects are kept, as they still grant access to the others.
+// Users and Groups are managed outside of the cluster so are always assumed to exist.
+func orphanedBindings(c dynamic.Interface, r schema.GroupVersionResource, n string, a []string) ([]Resource, error) {
+	list, err := c.Resource(r).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := existenceCache(c)
+	var resource []Resource
+	for _, binding := range list.Items {
+		if stringContainsArrayElement(binding.GetName(), a) {
+			continue
+		}
+
+		role, err := bindingRoleRef(binding)
+		if err != nil {
+			return nil, err
+		}
+		missing, err := missingRefs(exists, []objectRef{role})
+		if err != nil {
+			return nil, err
+		}
+
+		subjects, err := bindingSubjects(binding)
+		if err != nil {
+			return nil, err
+		}
+		missingSubjects, err := missingRefs(exists, subjects)
+		if err != nil {
+			return nil, err
+		}
+		if len(subjects) > 0 && len(missingSubjects) == len(subjects) {
+			missing = append(missing, missingSubjects...)
+		}
+
+		if len(missing) > 0 {
+			orphan, err := orphanedResource(binding, r, nsProtection, now, missing)
+			if err != nil {
+				return nil, err
+			}
+			resource = append(resource, orphan)
+		}
+	}
+
+	return resource, nil
+}
+
+// bindingRoleRef returns the Role (in the binding's namespace) or ClusterRole referenced by the binding.
+func bindingRoleRef(binding unstructured.Unstructured) (objectRef, error) {
+	kind, _, err := unstructured.NestedString(binding.Object, "roleRef", "kind")
+	if err != nil {
+		return objectRef{}, err
+	}
+	name, _, err := unstructured.NestedString(binding.Object, "roleRef", "name")
+	if err != nil {
+		return objectRef{}, err
+	}
+
+	if kind == "Role" {
+		return objectRef{RoleSchema, binding.GetNamespace(), name}, nil
+	}
+	return objectRef{ClusterRoleSchema, "", name}, nil
+}
+
+// bindingSubjects returns the ServiceAccount subjects of the binding, defaulting their namespace to the binding's.
+// None are returned when the binding has User or Group subjects, as those can't be looked up.
+func bindingSubjects(binding unstructured.Unstructured) ([]objectRef, error) {
+	subjects, _, err := unstructured.NestedSlice(binding.Object, "subjects")
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []objectRef
+	for _, s := range subjects {
+		subject, _ := s.(map[string]interface{})
+		if kind, _, _ := unstructured.NestedString(subject, "kind"); kind != "ServiceAccount" {
+			return nil, nil
+		}
+
+		name, _, _ := unstructured.NestedString(subject, "name")
+		namespace, _, _ := unstructured.NestedString(subject, "namespace")
+		if namespace == "" {
+			namespace = binding.GetNamespace()
+		}
+		refs = append(refs, objectRef{ServiceAccountSchema, namespace, name})
+	}
+
+	return refs, nil
+}
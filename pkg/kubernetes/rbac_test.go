@@ -0,0 +1,133 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	pod := newResource("v1", "Pod", "web-x2v9")
+	pod.Object["spec"] = map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "web"}}}
+
+	deployment := newResource("apps/v1", "Deployment", "deployer")
+	deployment.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{"serviceAccountName": "deployer"},
+		},
+	}
+
+	legacy := newResource("batch/v1", "Job", "legacy")
+	legacy.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{"serviceAccount": "legacy"},
+		},
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), pod, deployment, legacy,
+		newResource("v1", "ServiceAccount", "default"),
+		newResource("v1", "ServiceAccount", "deployer"),
+		newResource("v1", "ServiceAccount", "legacy"),
+		newResource("v1", "ServiceAccount", "unused"),
+	)
+
+	accounts, err := ServiceAccounts(client, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, sa := range accounts {
+		names = append(names, sa.Name)
+	}
+	if diff := cmp.Diff(names, []string{"deployer", "legacy", "unused"}); diff != "" {
+		t.Errorf("ServiceAccounts() differ (-got, +want): %s", diff)
+	}
+
+	used, err := UsedServiceAccounts(client, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := References{
+		"default":  {"pods/web-x2v9"},
+		"deployer": {"deployments/deployer"},
+		"legacy":   {"jobs/legacy"},
+	}
+	if diff := cmp.Diff(used, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestOrphanedBindings(t *testing.T) {
+	view := newResource("rbac.authorization.k8s.io/v1", "ClusterRole", "view")
+	view.SetNamespace("")
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), view,
+		newResource("rbac.authorization.k8s.io/v1", "Role", "deployer"),
+		newResource("v1", "ServiceAccount", "deployer"),
+		newBinding("RoleBinding", "deployer", "Role", "deployer", serviceAccountSubject("deployer", "")),
+		newBinding("RoleBinding", "removed-role", "Role", "removed", serviceAccountSubject("deployer", "")),
+		newBinding("RoleBinding", "removed-account", "ClusterRole", "view", serviceAccountSubject("removed", "")),
+		newBinding("RoleBinding", "partially-removed", "ClusterRole", "view",
+			serviceAccountSubject("deployer", ""), serviceAccountSubject("removed", "")),
+		newBinding("RoleBinding", "users", "ClusterRole", "view",
+			serviceAccountSubject("removed", ""), map[string]interface{}{"kind": "User", "name": "jane"}),
+		newBinding("ClusterRoleBinding", "cluster-deployer", "ClusterRole", "view", serviceAccountSubject("deployer", "default")),
+		newBinding("ClusterRoleBinding", "cluster-removed", "ClusterRole", "admin", serviceAccountSubject("ci", "build")),
+	)
+
+	bindings, err := OrphanedRoleBindings(client, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"removed-role":    {"roles/default/removed"},
+		"removed-account": {"serviceaccounts/default/removed"},
+	}
+	if diff := cmp.Diff(missing(bindings), expected); diff != "" {
+		t.Errorf("OrphanedRoleBindings() differ (-got, +want): %s", diff)
+	}
+
+	bindings, err = OrphanedClusterRoleBindings(client, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = map[string][]string{
+		"cluster-removed": {"clusterroles/admin", "serviceaccounts/build/ci"},
+	}
+	if diff := cmp.Diff(missing(bindings), expected); diff != "" {
+		t.Errorf("OrphanedClusterRoleBindings() differ (-got, +want): %s", diff)
+	}
+}
+
+func missing(list []Resource) map[string][]string {
+	m := make(map[string][]string)
+	for _, r := range list {
+		m[r.Name] = r.Missing
+	}
+	return m
+}
+
+func newBinding(kind, name, roleKind, role string, subjects ...interface{}) *unstructured.Unstructured {
+	binding := newResource("rbac.authorization.k8s.io/v1", kind, name)
+	if kind == "ClusterRoleBinding" {
+		binding.SetNamespace("")
+	}
+	binding.Object["roleRef"] = map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": roleKind, "name": role}
+	binding.Object["subjects"] = subjects
+	return binding
+}
+
+func serviceAccountSubject(name, namespace string) map[string]interface{} {
+	subject := map[string]interface{}{"kind": "ServiceAccount", "name": name}
+	if namespace != "" {
+		subject["namespace"] = namespace
+	}
+	return subject
+}
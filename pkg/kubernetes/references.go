@@ -0,0 +1,169 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// serviceAccountTokenType is the type of secrets holding a token for a ServiceAccount
+const serviceAccountTokenType = "kubernetes.io/service-account-token"
+
+// References maps the name of a referenced object to the objects referencing it (i.e. "deployments/web").
+type References map[string][]string
+
+// ReferenceProvider returns the configmaps and secrets referenced by a type of object in the namespace 'n'.
+type ReferenceProvider func(c dynamic.Interface, n string) (configs References, secrets References, err error)
+
+// ReferenceProviders are used by UsedConfigAndSecrets to determine what is in use.
+// Additional providers can be appended for other consumers of configmaps or secrets.
+var ReferenceProviders = []ReferenceProvider{
+	PodSpecReferences,
+	IngressReferences,
+	ServiceAccountReferences,
+	ServiceAccountTokenReferences,
+	CertificateReferences,
+}
+
+// IngressReferences is a ReferenceProvider for the TLS secrets of Ingresses (spec.tls[].secretName).
+func IngressReferences(c dynamic.Interface, n string) (References, References, error) {
+	list, err := listServed(c, n, IngressSchema, IngressV1Beta1Schema)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting ingresses")
+	}
+
+	secrets := make(References)
+	for _, ingress := range list.Items {
+		tls, _, err := unstructured.NestedSlice(ingress.Object, "spec", "tls")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, t := range tls {
+			if secret, found, _ := unstructured.NestedString(t.(map[string]interface{}), "secretName"); found {
+				secrets.add(owner(IngressSchema, ingress), secret)
+			}
+		}
+	}
+	return nil, secrets, nil
+}
+
+// ServiceAccountReferences is a ReferenceProvider for the secrets and imagePullSecrets of ServiceAccounts.
+func ServiceAccountReferences(c dynamic.Interface, n string) (References, References, error) {
+	list, err := listServed(c, n, ServiceAccountSchema)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting serviceaccounts")
+	}
+
+	secrets := make(References)
+	for _, sa := range list.Items {
+		for _, field := range []string{"secrets", "imagePullSecrets"} {
+			refs, _, err := unstructured.NestedSlice(sa.Object, field)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for _, ref := range refs {
+				if secret, found, _ := unstructured.NestedString(ref.(map[string]interface{}), "name"); found {
+					secrets.add(owner(ServiceAccountSchema, sa), secret)
+				}
+			}
+		}
+	}
+	return nil, secrets, nil
+}
+
+// ServiceAccountTokenReferences is a ReferenceProvider for service account token secrets,
+// which are in use by the ServiceAccount they were created for (even if it no longer lists them).
+func ServiceAccountTokenReferences(c dynamic.Interface, n string) (References, References, error) {
+	list, err := listServed(c, n, SecretSchema)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting secrets")
+	}
+
+	secrets := make(References)
+	for _, secret := range list.Items {
+		if t, _, _ := unstructured.NestedString(secret.Object, "type"); t != serviceAccountTokenType {
+			continue
+		}
+
+		sa := secret.GetAnnotations()[core_v1.ServiceAccountNameKey]
+		secrets.add(fmt.Sprintf("%s/%s", ServiceAccountSchema.Resource, sa), secret.GetName())
+	}
+	return nil, secrets, nil
+}
+
+// CertificateReferences is a ReferenceProvider for the secrets issued to cert-manager Certificates (spec.secretName).
+// Clusters without cert-manager installed have no references.
+func CertificateReferences(c dynamic.Interface, n string) (References, References, error) {
+	list, err := listServed(c, n, CertificateSchema)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting certificates")
+	}
+
+	secrets := make(References)
+	for _, certificate := range list.Items {
+		if secret, found, _ := unstructured.NestedString(certificate.Object, "spec", "secretName"); found {
+			secrets.add(owner(CertificateSchema, certificate), secret)
+		}
+	}
+	return nil, secrets, nil
+}
+
+// listServed lists the objects of the first resource type in 'r' served by the API server.
+// Types which aren't served at all (i.e. a CRD which isn't installed) return an empty list.
+func listServed(c dynamic.Interface, n string, r ...schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+	for _, resource := range r {
+		list, err := c.Resource(resource).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+		if !k8serrors.IsNotFound(err) {
+			return list, err
+		}
+	}
+	return &unstructured.UnstructuredList{}, nil
+}
+
+// owner identifies the object 'obj' of type 'r' as a referencing object, i.e. "deployments/web".
+func owner(r schema.GroupVersionResource, obj unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s", r.Resource, obj.GetName())
+}
+
+// add records 'owner' as referencing each of 'names', ignoring duplicates.
+func (r References) add(owner string, names ...string) {
+	for _, name := range names {
+		if !stringInSlice(owner, r[name]) {
+			r[name] = append(r[name], owner)
+		}
+	}
+}
+
+// merge adds all the references of 'o'.
+func (r References) merge(o References) {
+	for name, owners := range o {
+		for _, owner := range owners {
+			r.add(owner, name)
+		}
+	}
+}
+
+func (r References) sort() {
+	for _, owners := range r {
+		sort.Strings(owners)
+	}
+}
+
+func stringInSlice(s string, l []string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
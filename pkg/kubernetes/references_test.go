@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestReferenceProviders(t *testing.T) {
+	ingress := newResource("networking.k8s.io/v1", "Ingress", "web")
+	ingress.Object["spec"] = map[string]interface{}{
+		"tls": []interface{}{
+			map[string]interface{}{"hosts": []interface{}{"web.example.com"}, "secretName": "web-tls"},
+			map[string]interface{}{"hosts": []interface{}{"api.example.com"}, "secretName": "api-tls"},
+		},
+	}
+
+	sa := newResource("v1", "ServiceAccount", "deployer")
+	sa.Object["secrets"] = []interface{}{map[string]interface{}{"name": "deployer-token-x2v9"}}
+	sa.Object["imagePullSecrets"] = []interface{}{map[string]interface{}{"name": "registry"}}
+
+	token := newSecret("orphaned-token-b7k1")
+	token.Object["type"] = serviceAccountTokenType
+	token.SetAnnotations(map[string]string{"kubernetes.io/service-account.name": "removed"})
+
+	certificate := newResource("cert-manager.io/v1", "Certificate", "web")
+	certificate.Object["spec"] = map[string]interface{}{"secretName": "web-tls"}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), ingress, sa, token, newSecret("unused"), certificate)
+	_, secrets, err := UsedConfigAndSecrets(client, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := References{
+		"web-tls":             {"certificates/web", "ingresses/web"},
+		"api-tls":             {"ingresses/web"},
+		"deployer-token-x2v9": {"serviceaccounts/deployer"},
+		"registry":            {"serviceaccounts/deployer"},
+		"orphaned-token-b7k1": {"serviceaccounts/removed"},
+	}
+	if diff := cmp.Diff(secrets, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestListServedFallsBackToOlderVersions(t *testing.T) {
+	ingress := newResource("networking.k8s.io/v1beta1", "Ingress", "legacy")
+	ingress.Object["spec"] = map[string]interface{}{
+		"tls": []interface{}{map[string]interface{}{"secretName": "legacy-tls"}},
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), ingress)
+	client.PrependReactor("list", "*", func(a k8stesting.Action) (bool, runtime.Object, error) {
+		if r := a.GetResource(); r == IngressSchema || r == CertificateSchema {
+			return true, nil, k8serrors.NewNotFound(r.GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+
+	_, secrets, err := IngressReferences(client, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(secrets, References{"legacy-tls": {"ingresses/legacy"}}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", secrets, diff)
+	}
+
+	_, secrets, err = CertificateReferences(client, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 0 {
+		t.Errorf("CertificateReferences() got = %v, want none when cert-manager isn't installed", secrets)
+	}
+}
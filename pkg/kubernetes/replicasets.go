@@ -0,0 +1,113 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// revisionAnnotation is set by the Deployment controller on Deployments and their ReplicaSets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// StaleReplicaSets returns the ReplicaSets in the namespace 'n' which are no longer needed by their Deployment:
+// old revisions scaled to zero (beyond the newest 'keep' per Deployment), and those whose owning Deployment is missing.
+// ReplicaSets without a Deployment owner are managed by something else, so are never included.
+func StaleReplicaSets(c dynamic.Interface, n string, keep int, a []string) ([]Resource, error) {
+	if keep < 0 {
+		return nil, errors.Errorf("revisions to keep can't be negative: %d", keep)
+	}
+
+	list, err := c.Resource(ReplicaSetSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	deployments, err := c.Resource(DeploymentSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting deployments")
+	}
+	revisions := make(map[string]int64)
+	for _, deployment := range deployments.Items {
+		revisions[string(deployment.GetUID())] = revision(deployment)
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource []Resource
+	old := make(map[string][]unstructured.Unstructured)
+	for _, rs := range list.Items {
+		deployment := controllingDeployment(rs)
+		if deployment == nil || stringContainsArrayElement(rs.GetName(), a) {
+			continue
+		}
+
+		current, found := revisions[string(deployment.UID)]
+		if !found {
+			ref := objectRef{DeploymentSchema, rs.GetNamespace(), deployment.Name}
+			orphan, err := orphanedResource(rs, ReplicaSetSchema, nsProtection, now, []string{ref.String()})
+			if err != nil {
+				return nil, err
+			}
+			resource = append(resource, orphan)
+			continue
+		}
+
+		replicas, found, _ := unstructured.NestedInt64(rs.Object, "spec", "replicas")
+		if found && replicas == 0 && revision(rs) != current {
+			old[deployment.Name] = append(old[deployment.Name], rs)
+		}
+	}
+
+	var deploymentNames []string
+	for name := range old {
+		deploymentNames = append(deploymentNames, name)
+	}
+	sort.Strings(deploymentNames)
+
+	for _, name := range deploymentNames {
+		replicaSets := old[name]
+		sort.SliceStable(replicaSets, func(i, j int) bool {
+			return revision(replicaSets[i]) > revision(replicaSets[j])
+		})
+		if keep >= len(replicaSets) {
+			continue
+		}
+
+		for _, rs := range replicaSets[keep:] {
+			stale, err := orphanedResource(rs, ReplicaSetSchema, nsProtection, now, nil)
+			if err != nil {
+				return nil, err
+			}
+			stale.Details = fmt.Sprintf("deployment=%s revision=%d current=%d", name, revision(rs), revisions[string(controllingDeployment(rs).UID)])
+			resource = append(resource, stale)
+		}
+	}
+
+	return resource, nil
+}
+
+// controllingDeployment returns the owner reference of the Deployment controlling the ReplicaSet 'rs', if any.
+func controllingDeployment(rs unstructured.Unstructured) *meta_v1.OwnerReference {
+	ref := meta_v1.GetControllerOf(&rs)
+	if ref == nil || ref.Kind != "Deployment" {
+		return nil
+	}
+	return ref
+}
+
+// revision returns the revision of a Deployment or ReplicaSet, or zero when it isn't set.
+func revision(obj unstructured.Unstructured) int64 {
+	r, _ := strconv.ParseInt(obj.GetAnnotations()[revisionAnnotation], 10, 64)
+	return r
+}
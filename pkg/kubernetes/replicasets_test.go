@@ -0,0 +1,92 @@
+package kubernetes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestStaleReplicaSets(t *testing.T) {
+	web := newResource("apps/v1", "Deployment", "web")
+	web.SetUID("web-uid")
+	web.SetAnnotations(map[string]string{revisionAnnotation: "4"})
+
+	objects := []runtime.Object{web,
+		newReplicaSet("web-1", "web", "web-uid", 1, 0),
+		newReplicaSet("web-2", "web", "web-uid", 2, 0),
+		newReplicaSet("web-3", "web", "web-uid", 3, 1),
+		newReplicaSet("web-4", "web", "web-uid", 4, 3),
+		newReplicaSet("removed-1", "removed", "removed-uid", 1, 0),
+		newReplicaSet("bare", "", "", 0, 0),
+	}
+
+	tests := []struct {
+		name string
+		keep int
+		want map[string]string
+	}{
+		{
+			name: "Old revisions scaled to zero and orphans are stale",
+			want: map[string]string{
+				"web-2":     "deployment=web revision=2 current=4",
+				"web-1":     "deployment=web revision=1 current=4",
+				"removed-1": "MISSING deployments/default/removed",
+			},
+		},
+		{
+			name: "The newest old revisions are kept",
+			keep: 1,
+			want: map[string]string{
+				"web-1":     "deployment=web revision=1 current=4",
+				"removed-1": "MISSING deployments/default/removed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+			replicaSets, err := StaleReplicaSets(client, "default", tt.keep, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			for _, rs := range replicaSets {
+				got[rs.Name] = rs.Details
+				if len(rs.Missing) > 0 {
+					got[rs.Name] = "MISSING " + rs.Missing[0]
+				}
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("StaleReplicaSets() differ (-got, +want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStaleReplicaSetsNegativeKeep(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), newReplicaSet("web-1", "web", "web-uid", 1, 0))
+	if _, err := StaleReplicaSets(client, "default", -1, nil); err == nil {
+		t.Error("expected an error keeping a negative number of revisions")
+	}
+}
+
+func newReplicaSet(name, deployment, uid string, revision, replicas int64) *unstructured.Unstructured {
+	rs := newResource("apps/v1", "ReplicaSet", name)
+	rs.SetAnnotations(map[string]string{revisionAnnotation: fmt.Sprint(revision)})
+	rs.Object["spec"] = map[string]interface{}{"replicas": replicas}
+	if deployment != "" {
+		controller := true
+		rs.SetOwnerReferences([]meta_v1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: deployment, UID: types.UID(uid), Controller: &controller},
+		})
+	}
+	return rs
+}
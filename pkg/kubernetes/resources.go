@@ -15,11 +15,19 @@ import (
 
 // Resource is a stripped down version of a Kubernetes Resource.
 // It only holds the name age and (optional) status of the resource.
+// Protected is why the object is exempt from clean-up (see KeepAnnotation), empty when it isn't.
+// Expires is when the object expired (see TTLAnnotation), only set by ResourcesOlderThan.
+// Details are additional information about the object for the user (i.e. a volume's capacity).
+// Missing are the objects referenced by an orphaned object which no longer exist (i.e. "clusterroles/admin").
 type Resource struct {
-	Name   string
-	Kind   string
-	Age    time.Duration
-	Status Status
+	Name      string
+	Kind      string
+	Age       time.Duration
+	Status    Status
+	Protected string
+	Expires   time.Time
+	Details   string
+	Missing   []string
 }
 
 type Status string
@@ -32,53 +40,88 @@ const (
 )
 
 // Resources returns all the existing objects for a given resource type.
-func Resources(c dynamic.Interface, r schema.GroupVersionResource, n string, a []string) ([]string, error) {
+func Resources(c dynamic.Interface, r schema.GroupVersionResource, n string, a []string) ([]Resource, error) {
 	list, err := c.Resource(r).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	var resources []string
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []Resource
 	for _, resource := range list.Items {
 		name, found, err := unstructured.NestedString(resource.Object, "metadata", "name")
 		if err != nil || !found {
 			return nil, err
 		}
 
+		age, err := objectAge(resource)
+		if err != nil {
+			return nil, err
+		}
+
 		if !stringContainsArrayElement(name, a) {
-			resources = append(resources, name)
+			resources = append(resources, Resource{
+				Name:      name,
+				Kind:      r.Resource,
+				Age:       age,
+				Protected: objectProtection(&resource, nsProtection, now),
+			})
 		}
 	}
 
 	return resources, nil
 }
 
-// ResourcesOlderThan returns a list of the resources older than the duration 'd'.
+// ResourcesOlderThan returns a list of the resources which have expired, which by default is when older than the duration 'd'.
+// Objects can override this with their own TTLAnnotation or ExpiresAtAnnotation. Objects with invalid annotations are
+// returned as protected, so they're reported without being deleted.
 func ResourcesOlderThan(c dynamic.Interface, r schema.GroupVersionResource, n string, d time.Duration, a []string) ([]Resource, error) {
 	list, err := c.Resource(r).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
 	if err != nil {
 		return nil, errors.Wrap(err, "getting resource")
 	}
 
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
 	var resource []Resource
 	for _, deployment := range list.Items {
-		age, err := objectAge(deployment)
+		created, err := objectCreation(deployment)
 		if err != nil {
 			return nil, err
 		}
-		if age > d {
-			name, found, err := unstructured.NestedString(deployment.Object, "metadata", "name")
-			if err != nil || !found {
-				return nil, err
-			}
 
-			if !stringContainsArrayElement(name, a) {
-				resource = append(resource, Resource{
-					Name: name,
-					Kind: r.Resource,
-					Age:  age.Round(time.Minute),
-				})
+		protected := objectProtection(&deployment, nsProtection, now)
+		expires, err := expiry(&deployment, created, d)
+		if err != nil {
+			if protected == "" {
+				protected = err.Error()
 			}
+		} else if !now.After(expires) {
+			continue
+		}
+
+		name, found, err := unstructured.NestedString(deployment.Object, "metadata", "name")
+		if err != nil || !found {
+			return nil, err
+		}
+
+		if !stringContainsArrayElement(name, a) {
+			resource = append(resource, Resource{
+				Name:      name,
+				Kind:      r.Resource,
+				Age:       now.Sub(created).Round(time.Minute),
+				Protected: protected,
+				Expires:   expires,
+			})
 		}
 	}
 
@@ -95,6 +138,16 @@ func DeleteResource(c dynamic.Interface, r schema.GroupVersionResource, ns, n st
 	return c.Resource(r).Namespace(ns).Delete(context.TODO(), n, deleteOptions)
 }
 
+// objectCreation returns the creation time of the object.
+func objectCreation(obj unstructured.Unstructured) (time.Time, error) {
+	t, found, err := unstructured.NestedString(obj.Object, "metadata", "creationTimestamp")
+	if err != nil || !found {
+		return time.Time{}, fmt.Errorf("unable to parse 'creationTimestamp' %s", err)
+	}
+
+	return time.Parse(time.RFC3339, t)
+}
+
 func objectAge(obj unstructured.Unstructured) (time.Duration, error) {
 	t, found, err := unstructured.NestedString(obj.Object, "metadata", "creationTimestamp")
 	if err != nil || !found {
@@ -105,6 +158,14 @@ func objectAge(obj unstructured.Unstructured) (time.Duration, error) {
 	return time.Now().Sub(creation).Round(time.Minute), nil
 }
 
+// objectProtection returns the namespace's protection 'ns' if set, otherwise the object's own protection.
+func objectProtection(obj *unstructured.Unstructured, ns string, now time.Time) string {
+	if ns != "" {
+		return ns
+	}
+	return protection(obj, now)
+}
+
 func stringContainsArrayElement(s string, t []string) bool {
 	for _, e := range t {
 		if strings.Contains(s, e) {
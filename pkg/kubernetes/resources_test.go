@@ -2,6 +2,7 @@ package kubernetes
 
 import (
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -61,7 +62,7 @@ func TestResourcesOlderThan(t *testing.T) {
 				t.Errorf("Unexpected error: %s", err)
 				return
 			}
-			if diff := cmp.Diff(actual, test.expected); diff != "" {
+			if diff := cmp.Diff(actual, test.expected, cmpopts.IgnoreFields(Resource{}, "Expires")); diff != "" {
 				t.Errorf("%T differ (-got, +want): %s", test.expected, diff)
 				return
 			}
@@ -77,7 +78,7 @@ func TestResources(t *testing.T) {
 		resource schema.GroupVersionResource
 		allow    []string
 		client   dynamic.Interface
-		expected []string
+		expected []Resource
 	}{
 		{
 			name:     "Testing ConfigMaps with complete match allow-list",
@@ -88,7 +89,10 @@ func TestResources(t *testing.T) {
 				newConfigmap("env-vars"),
 				newConfigmap("allowed-config"),
 			),
-			expected: []string{"properties", "env-vars"},
+			expected: []Resource{
+				{Name: "properties", Kind: "configmaps"},
+				{Name: "env-vars", Kind: "configmaps"},
+			},
 		},
 		{
 			name:     "Testing Secrets with contains match allow-list",
@@ -99,7 +103,10 @@ func TestResources(t *testing.T) {
 				newSecret("certs"),
 				newSecret("allowed-secret"),
 			),
-			expected: []string{"tokens", "certs"},
+			expected: []Resource{
+				{Name: "tokens", Kind: "secrets"},
+				{Name: "certs", Kind: "secrets"},
+			},
 		},
 	}
 
@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// defaultServiceAccount is created in every namespace, and re-created by the controller manager if deleted
+const defaultServiceAccount = "default"
+
+// ServiceAccounts returns the ServiceAccounts in the namespace 'n', excluding the 'default' ServiceAccount.
+func ServiceAccounts(c dynamic.Interface, n string, a []string) ([]Resource, error) {
+	list, err := Resources(c, ServiceAccountSchema, n, a)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource []Resource
+	for _, sa := range list {
+		if sa.Name != defaultServiceAccount {
+			resource = append(resource, sa)
+		}
+	}
+	return resource, nil
+}
+
+// UsedServiceAccounts returns the ServiceAccounts used by pods and workload templates in the namespace 'n'.
+func UsedServiceAccounts(c dynamic.Interface, n string) (References, error) {
+	accounts := make(References)
+	err := forEachPodSpec(c, n, func(by string, _ map[string]string, spec core_v1.PodSpec) {
+		name := spec.ServiceAccountName
+		if name == "" {
+			// serviceAccount is the deprecated alias of serviceAccountName
+			name = spec.DeprecatedServiceAccount
+		}
+		if name == "" {
+			name = defaultServiceAccount
+		}
+		accounts.add(by, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts.sort()
+	return accounts, nil
+}
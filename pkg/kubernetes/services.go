@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+)
+
+// endpointSliceServiceLabel is set on EndpointSlices to the name of the Service they belong to
+const endpointSliceServiceLabel = "kubernetes.io/service-name"
+
+// SelectorServices returns the Services in the namespace 'n' which select pods using a label selector.
+// ExternalName Services and Services without a selector (which have their endpoints managed manually) are excluded.
+func SelectorServices(c dynamic.Interface, n string, a []string) ([]Resource, error) {
+	list, err := c.Resource(ServiceSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource []Resource
+	for _, svc := range list.Items {
+		age, err := objectAge(svc)
+		if err != nil {
+			return nil, err
+		}
+
+		selector, err := serviceSelector(svc)
+		if err != nil {
+			return nil, err
+		}
+
+		if selector != nil && !stringContainsArrayElement(svc.GetName(), a) {
+			resource = append(resource, Resource{
+				Name:      svc.GetName(),
+				Kind:      ServiceSchema.Resource,
+				Age:       age.Round(time.Minute),
+				Protected: objectProtection(&svc, nsProtection, now),
+			})
+		}
+	}
+
+	return resource, nil
+}
+
+// UsedServices returns the Services in the namespace 'n' whose selector matches a pod or workload template,
+// or which have ready or not-ready addresses in their Endpoints or EndpointSlices.
+func UsedServices(c dynamic.Interface, n string) (References, error) {
+	list, err := c.Resource(ServiceSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting services")
+	}
+
+	selectors := make(map[string]labels.Selector)
+	for _, svc := range list.Items {
+		selector, err := serviceSelector(svc)
+		if err != nil {
+			return nil, err
+		} else if selector != nil {
+			selectors[svc.GetName()] = selector
+		}
+	}
+
+	services := make(References)
+	err = forEachPodSpec(c, n, func(by string, l map[string]string, _ core_v1.PodSpec) {
+		for name, selector := range selectors {
+			if selector.Matches(labels.Set(l)) {
+				services.add(by, name)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := listServed(c, n, EndpointsSchema)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting endpoints")
+	}
+	for _, e := range endpoints.Items {
+		subsets, _, err := unstructured.NestedSlice(e.Object, "subsets")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, subset := range subsets {
+			addresses, _, _ := unstructured.NestedSlice(subset.(map[string]interface{}), "addresses")
+			notReady, _, _ := unstructured.NestedSlice(subset.(map[string]interface{}), "notReadyAddresses")
+			if len(addresses)+len(notReady) > 0 {
+				services.add(owner(EndpointsSchema, e), e.GetName())
+			}
+		}
+	}
+
+	slices, err := listServed(c, n, EndpointSliceSchema, EndpointSliceV1Beta1Schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting endpointslices")
+	}
+	for _, slice := range slices.Items {
+		e, _, err := unstructured.NestedSlice(slice.Object, "endpoints")
+		if err != nil {
+			return nil, err
+		}
+
+		if svc := slice.GetLabels()[endpointSliceServiceLabel]; svc != "" && len(e) > 0 {
+			services.add(owner(EndpointSliceSchema, slice), svc)
+		}
+	}
+
+	services.sort()
+	return services, nil
+}
+
+// serviceSelector returns the label selector of the Service 'svc', or nil for ExternalName and selector-less Services.
+func serviceSelector(svc unstructured.Unstructured) (labels.Selector, error) {
+	if t, _, _ := unstructured.NestedString(svc.Object, "spec", "type"); t == string(core_v1.ServiceTypeExternalName) {
+		return nil, nil
+	}
+
+	selector, _, err := unstructured.NestedStringMap(svc.Object, "spec", "selector")
+	if err != nil || len(selector) == 0 {
+		return nil, err
+	}
+	return labels.SelectorFromSet(selector), nil
+}
@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestServices(t *testing.T) {
+	pod := newResource("v1", "Pod", "web-x2v9")
+	pod.SetLabels(map[string]string{"app": "web", "pod-template-hash": "5d8f"})
+	pod.Object["spec"] = map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "web"}}}
+
+	deployment := newResource("apps/v1", "Deployment", "worker")
+	deployment.Object["spec"] = map[string]interface{}{
+		"replicas": int64(0),
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "worker"}},
+			"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "worker"}}},
+		},
+	}
+
+	endpoints := newResource("v1", "Endpoints", "migrated")
+	endpoints.Object["subsets"] = []interface{}{
+		map[string]interface{}{"notReadyAddresses": []interface{}{map[string]interface{}{"ip": "10.0.0.1"}}},
+	}
+	emptyEndpoints := newResource("v1", "Endpoints", "dead")
+
+	slice := newResource("discovery.k8s.io/v1", "EndpointSlice", "sliced-abc12")
+	slice.SetLabels(map[string]string{endpointSliceServiceLabel: "sliced"})
+	slice.Object["endpoints"] = []interface{}{map[string]interface{}{"addresses": []interface{}{"10.0.0.2"}}}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), pod, deployment, endpoints, emptyEndpoints, slice,
+		newService("web", map[string]interface{}{"selector": map[string]interface{}{"app": "web"}}),
+		newService("worker", map[string]interface{}{"selector": map[string]interface{}{"app": "worker"}}),
+		newService("migrated", map[string]interface{}{"selector": map[string]interface{}{"app": "migrated"}}),
+		newService("sliced", map[string]interface{}{"selector": map[string]interface{}{"app": "sliced"}}),
+		newService("dead", map[string]interface{}{"selector": map[string]interface{}{"app": "deleted"}}),
+		newService("external", map[string]interface{}{"type": "ExternalName", "externalName": "db.example.com"}),
+		newService("manual", map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(5432)}}}),
+	)
+
+	services, err := SelectorServices(client, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, svc := range services {
+		names = append(names, svc.Name)
+	}
+	if diff := cmp.Diff(names, []string{"web", "worker", "migrated", "sliced", "dead"}); diff != "" {
+		t.Errorf("SelectorServices() differ (-got, +want): %s", diff)
+	}
+
+	used, err := UsedServices(client, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := References{
+		"web":      {"pods/web-x2v9"},
+		"worker":   {"deployments/worker"},
+		"migrated": {"endpoints/migrated"},
+		"sliced":   {"endpointslices/sliced-abc12"},
+	}
+	if diff := cmp.Diff(used, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func newService(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	svc := newResource("v1", "Service", name)
+	svc.Object["spec"] = spec
+	return svc
+}
@@ -3,13 +3,44 @@ package kubernetes
 import "k8s.io/apimachinery/pkg/runtime/schema"
 
 var (
+	NamespaceSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
 	PodSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
 	ConfigMapSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
 	SecretSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
 	ServiceSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+	ServiceAccountSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "serviceaccounts"}
+	EndpointsSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}
+	PersistentVolumeClaimSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}
+	PersistentVolumeSchema = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"}
 
 	DeploymentSchema = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
 	StatefulSetSchema = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	DaemonSetSchema = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}
+	ReplicaSetSchema = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
 
 	JobSchema = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	CronJobSchema = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}
+	// CronJobV1Beta1Schema is used for clusters older than v1.21, where CronJobs aren't served as batch/v1
+	CronJobV1Beta1Schema = schema.GroupVersionResource{Group: "batch", Version: "v1beta1", Resource: "cronjobs"}
+
+	HorizontalPodAutoscalerSchema = schema.GroupVersionResource{Group: "autoscaling", Version: "v1", Resource: "horizontalpodautoscalers"}
+
+	RoleSchema = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}
+	RoleBindingSchema = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}
+	ClusterRoleSchema = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}
+	ClusterRoleBindingSchema = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}
+
+	IngressSchema = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	// IngressV1Beta1Schema is used for clusters older than v1.19, where Ingresses aren't served as networking.k8s.io/v1
+	IngressV1Beta1Schema = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"}
+
+	EndpointSliceSchema = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
+	// EndpointSliceV1Beta1Schema is used for clusters older than v1.21, where EndpointSlices aren't served as discovery.k8s.io/v1
+	EndpointSliceV1Beta1Schema = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1beta1", Resource: "endpointslices"}
+
+	// PodMetricsSchema is served by metrics-server (or another metrics API implementation), when installed
+	PodMetricsSchema = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+	// CertificateSchema is a cert-manager Certificate, which may not be installed in the cluster
+	CertificateSchema = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
 )
@@ -0,0 +1,201 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// WorkloadUsage is the resource usage of a workload's pods, alongside what they request and are limited to.
+type WorkloadUsage struct {
+	Name string
+	Kind string
+	Age  time.Duration
+
+	// Pods are the number of the workload's pods with metrics, which the totals are summed from
+	Pods int
+
+	// CPU and Memory are the totals across the workload's pods
+	CPU    ResourceUsage
+	Memory ResourceUsage
+}
+
+// ResourceUsage is the usage of a single resource (i.e. CPU) across pods.
+// Requests (or Limits) are zero when any container doesn't set them, as the total is then unbounded.
+type ResourceUsage struct {
+	Usage    resource.Quantity
+	Requests resource.Quantity
+	Limits   resource.Quantity
+}
+
+// PercentOfLimits returns the usage as a percentage of the limits, or false when they aren't set.
+func (u ResourceUsage) PercentOfLimits() (float64, bool) {
+	return percent(u.Usage, u.Limits)
+}
+
+// PercentOfRequests returns the usage as a percentage of the requests, or false when they aren't set.
+func (u ResourceUsage) PercentOfRequests() (float64, bool) {
+	return percent(u.Usage, u.Requests)
+}
+
+func percent(q, of resource.Quantity) (float64, bool) {
+	if of.IsZero() {
+		return 0, false
+	}
+	return float64(q.MilliValue()) / float64(of.MilliValue()) * 100, true
+}
+
+// WorkloadUsages returns the resource usage of the workloads of type 'r' (i.e. Deployments) in the namespace 'n',
+// from the pod metrics of the 'metrics.k8s.io' API. Workloads without any pod metrics are excluded.
+func WorkloadUsages(c dynamic.Interface, r schema.GroupVersionResource, n string, a []string) ([]WorkloadUsage, error) {
+	list, err := c.Resource(r).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	metrics, err := c.Resource(PodMetricsSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, errors.New("the metrics API isn't available, is metrics-server installed?")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error getting pod metrics")
+	}
+
+	usages := make(map[string]core_v1.ResourceList)
+	for _, m := range metrics.Items {
+		containers, _, err := unstructured.NestedSlice(m.Object, "containers")
+		if err != nil {
+			return nil, err
+		}
+
+		usage := core_v1.ResourceList{}
+		for _, container := range containers {
+			values, _, _ := unstructured.NestedStringMap(container.(map[string]interface{}), "usage")
+			if err := addQuantities(usage, values); err != nil {
+				return nil, errors.Wrapf(err, "parsing metrics of %s", m.GetName())
+			}
+		}
+		usages[m.GetName()] = usage
+	}
+
+	pods, err := c.Resource(PodSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting pods")
+	}
+
+	var workloads []WorkloadUsage
+	for _, workload := range list.Items {
+		if stringContainsArrayElement(workload.GetName(), a) {
+			continue
+		}
+
+		selector, err := workloadSelector(workload)
+		if err != nil {
+			return nil, err
+		} else if selector == nil {
+			continue
+		}
+
+		w := WorkloadUsage{Name: workload.GetName(), Kind: r.Resource}
+		cpu := &resourceTotals{}
+		memory := &resourceTotals{}
+		for _, pod := range pods.Items {
+			usage, found := usages[pod.GetName()]
+			if !found || !selector.Matches(labels.Set(pod.GetLabels())) {
+				continue
+			}
+
+			spec, err := podSpec(pod, "spec")
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing pods %s", pod.GetName())
+			}
+
+			w.Pods++
+			cpu.add(core_v1.ResourceCPU, usage, spec)
+			memory.add(core_v1.ResourceMemory, usage, spec)
+		}
+
+		if w.Pods == 0 {
+			continue
+		}
+
+		age, err := objectAge(workload)
+		if err != nil {
+			return nil, err
+		}
+
+		w.Age = age.Round(time.Minute)
+		w.CPU = cpu.usage()
+		w.Memory = memory.usage()
+		workloads = append(workloads, w)
+	}
+
+	return workloads, nil
+}
+
+// resourceTotals sums the usage, requests and limits of a resource across pods.
+// Requests (or limits) become unbounded once a container without them is added.
+// Init containers aren't included, as they've finished before metrics are collected.
+type resourceTotals struct {
+	ResourceUsage
+	unboundedRequests bool
+	unboundedLimits   bool
+}
+
+func (t *resourceTotals) add(name core_v1.ResourceName, usage core_v1.ResourceList, spec core_v1.PodSpec) {
+	if q, found := usage[name]; found {
+		t.Usage.Add(q)
+	}
+
+	for _, container := range spec.Containers {
+		limit, limited := container.Resources.Limits[name]
+		if limited {
+			t.Limits.Add(limit)
+		} else {
+			t.unboundedLimits = true
+		}
+
+		// containers with a limit but no request, request their limit
+		if q, found := container.Resources.Requests[name]; found {
+			t.Requests.Add(q)
+		} else if limited {
+			t.Requests.Add(limit)
+		} else {
+			t.unboundedRequests = true
+		}
+	}
+}
+
+func (t *resourceTotals) usage() ResourceUsage {
+	u := t.ResourceUsage
+	if t.unboundedRequests {
+		u.Requests = resource.Quantity{}
+	}
+	if t.unboundedLimits {
+		u.Limits = resource.Quantity{}
+	}
+	return u
+}
+
+// addQuantities parses the quantities in 'values' (i.e. {"cpu": "250m"}) and adds them to the totals in 'l'.
+func addQuantities(l core_v1.ResourceList, values map[string]string) error {
+	for name, value := range values {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return err
+		}
+
+		total := l[core_v1.ResourceName(name)]
+		total.Add(q)
+		l[core_v1.ResourceName(name)] = total
+	}
+	return nil
+}
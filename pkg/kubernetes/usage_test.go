@@ -0,0 +1,106 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWorkloadUsages(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newWorkload("apps/v1", "Deployment", "web", 2, nil),
+		newWorkload("apps/v1", "Deployment", "unbounded", 1, nil),
+		newWorkload("apps/v1", "Deployment", "no-metrics", 1, nil),
+		newUsagePod("web-a", "web", map[string]interface{}{"cpu": "250m", "memory": "128Mi"}, map[string]interface{}{"cpu": "500m"}),
+		newUsagePod("web-b", "web", map[string]interface{}{"cpu": "250m", "memory": "128Mi"}, map[string]interface{}{"cpu": "500m"}),
+		newUsagePod("unbounded-a", "unbounded", nil, nil),
+		newUsagePod("no-metrics-a", "no-metrics", nil, nil),
+	)
+	createPodMetrics(t, client, "web-a", "450m", "100Mi")
+	createPodMetrics(t, client, "web-b", "500m", "100Mi")
+	createPodMetrics(t, client, "unbounded-a", "10m", "20Mi")
+
+	workloads, err := WorkloadUsages(client, DeploymentSchema, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []WorkloadUsage{
+		{Name: "web", Kind: "deployments", Pods: 2,
+			CPU:    ResourceUsage{Usage: resource.MustParse("950m"), Requests: resource.MustParse("500m"), Limits: resource.MustParse("1")},
+			Memory: ResourceUsage{Usage: resource.MustParse("200Mi"), Requests: resource.MustParse("256Mi")},
+		},
+		{Name: "unbounded", Kind: "deployments", Pods: 1,
+			CPU:    ResourceUsage{Usage: resource.MustParse("10m")},
+			Memory: ResourceUsage{Usage: resource.MustParse("20Mi")},
+		},
+	}
+	if diff := cmp.Diff(workloads, expected, cmp.Comparer(func(a, b resource.Quantity) bool { return a.Cmp(b) == 0 })); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	if p, limited := workloads[0].CPU.PercentOfLimits(); !limited || p != 95 {
+		t.Errorf("expected cpu at 95%% of limits, got %v (%v)", p, limited)
+	}
+	if _, limited := workloads[0].Memory.PercentOfLimits(); limited {
+		t.Error("expected memory without limits")
+	}
+	if p, requested := workloads[0].Memory.PercentOfRequests(); !requested || p != 78.125 {
+		t.Errorf("expected memory at 78.125%% of requests, got %v (%v)", p, requested)
+	}
+}
+
+func TestWorkloadUsagesWithoutMetricsAPI(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), newWorkload("apps/v1", "Deployment", "web", 1, nil))
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource() == PodMetricsSchema {
+			return true, nil, k8serrors.NewNotFound(PodMetricsSchema.GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+
+	if _, err := WorkloadUsages(client, DeploymentSchema, "default", nil); err == nil {
+		t.Error("expected an error without the metrics API")
+	}
+}
+
+// newUsagePod returns a pod labelled for the workload 'app' with a single container which requests and is limited to
+// the given resources, either can be nil.
+func newUsagePod(name, app string, requests, limits map[string]interface{}) *unstructured.Unstructured {
+	pod := newResource("v1", "Pod", name)
+	pod.SetLabels(map[string]string{"app": app})
+
+	resources := map[string]interface{}{}
+	if requests != nil {
+		resources["requests"] = requests
+	}
+	if limits != nil {
+		resources["limits"] = limits
+	}
+	pod.Object["spec"] = map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "app", "image": "app", "resources": resources}},
+	}
+	return pod
+}
+
+// createPodMetrics adds the metrics of the pod 'name', which can't be passed to the fake client's constructor as
+// it would guess their resource from the kind.
+func createPodMetrics(t *testing.T, c *fake.FakeDynamicClient, name, cpu, memory string) {
+	metrics := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		"containers": []interface{}{map[string]interface{}{"name": "app", "usage": map[string]interface{}{"cpu": cpu, "memory": memory}}},
+	}}
+	if _, err := c.Resource(PodMetricsSchema).Namespace("default").Create(context.TODO(), metrics, meta_v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
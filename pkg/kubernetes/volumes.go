@@ -0,0 +1,157 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// Released volumes were bound to a claim which has since been deleted
+	Released Status = "Released"
+)
+
+// UsedPersistentVolumeClaims returns the claims referenced by pods and workload templates in the namespace 'n'.
+// Claims created from a StatefulSet's volumeClaimTemplates ('<template>-<statefulset>-<ordinal>') are in use by the
+// StatefulSet, as they're re-attached when it scales back up.
+func UsedPersistentVolumeClaims(c dynamic.Interface, n string) (References, error) {
+	claims := make(References)
+	err := forEachPodSpec(c, n, func(by string, _ map[string]string, spec core_v1.PodSpec) {
+		for _, volume := range spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claims.add(by, volume.PersistentVolumeClaim.ClaimName)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statefulSets, err := c.Resource(StatefulSetSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting statefulsets")
+	}
+
+	var templates []*regexp.Regexp
+	var owners []string
+	for _, sts := range statefulSets.Items {
+		vcts, _, err := unstructured.NestedSlice(sts.Object, "spec", "volumeClaimTemplates")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, vct := range vcts {
+			name, _, _ := unstructured.NestedString(vct.(map[string]interface{}), "metadata", "name")
+			templates = append(templates, regexp.MustCompile(fmt.Sprintf(`^%s-%s-\d+$`, regexp.QuoteMeta(name), regexp.QuoteMeta(sts.GetName()))))
+			owners = append(owners, owner(StatefulSetSchema, sts))
+		}
+	}
+
+	if len(templates) > 0 {
+		pvcs, err := c.Resource(PersistentVolumeClaimSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting persistentvolumeclaims")
+		}
+
+		for _, pvc := range pvcs.Items {
+			for i, template := range templates {
+				if template.MatchString(pvc.GetName()) {
+					claims.add(owners[i], pvc.GetName())
+				}
+			}
+		}
+	}
+
+	claims.sort()
+	return claims, nil
+}
+
+// PersistentVolumeClaims returns all the claims in the namespace 'n', with their capacity and storage class as details.
+func PersistentVolumeClaims(c dynamic.Interface, n string, a []string) ([]Resource, error) {
+	list, err := c.Resource(PersistentVolumeClaimSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource []Resource
+	for _, pvc := range list.Items {
+		age, err := objectAge(pvc)
+		if err != nil {
+			return nil, err
+		}
+
+		if !stringContainsArrayElement(pvc.GetName(), a) {
+			capacity, _, _ := unstructured.NestedString(pvc.Object, "status", "capacity", "storage")
+			class, _, _ := unstructured.NestedString(pvc.Object, "spec", "storageClassName")
+			resource = append(resource, Resource{
+				Name:      pvc.GetName(),
+				Kind:      PersistentVolumeClaimSchema.Resource,
+				Age:       age.Round(time.Minute),
+				Protected: objectProtection(&pvc, nsProtection, now),
+				Details:   volumeDetails(capacity, class),
+			})
+		}
+	}
+
+	return resource, nil
+}
+
+// ReleasedPersistentVolumes returns the volumes in the 'Released' or 'Failed' phase, which are no longer bound to a claim.
+// The capacity and storage class of each volume are included as details.
+func ReleasedPersistentVolumes(c dynamic.Interface, a []string) ([]Resource, error) {
+	list, err := c.Resource(PersistentVolumeSchema).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	now := time.Now()
+	var resource []Resource
+	for _, pv := range list.Items {
+		age, err := objectAge(pv)
+		if err != nil {
+			return nil, err
+		}
+
+		phase, _, _ := unstructured.NestedString(pv.Object, "status", "phase")
+		if !stringContainsArrayElement(pv.GetName(), a) && (Status(phase) == Released || Status(phase) == Failed) {
+			capacity, _, _ := unstructured.NestedString(pv.Object, "spec", "capacity", "storage")
+			class, _, _ := unstructured.NestedString(pv.Object, "spec", "storageClassName")
+			resource = append(resource, Resource{
+				Name:      pv.GetName(),
+				Kind:      PersistentVolumeSchema.Resource,
+				Age:       age.Round(time.Minute),
+				Status:    Status(phase),
+				Protected: protection(&pv, now),
+				Details:   volumeDetails(capacity, class),
+			})
+		}
+	}
+
+	return resource, nil
+}
+
+// volumeDetails describes a volume by its capacity and storage class, i.e. "capacity=10Gi storageClass=standard".
+func volumeDetails(capacity, class string) string {
+	var details []string
+	if capacity != "" {
+		details = append(details, "capacity="+capacity)
+	}
+	if class != "" {
+		details = append(details, "storageClass="+class)
+	}
+	return strings.Join(details, " ")
+}
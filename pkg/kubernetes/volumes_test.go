@@ -0,0 +1,128 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestUsedPersistentVolumeClaims(t *testing.T) {
+	pod := newResource("v1", "Pod", "app")
+	pod.Object["spec"] = map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "app"}},
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "app-data"}},
+		},
+	}
+
+	deployment := newResource("apps/v1", "Deployment", "scaled-down")
+	deployment.Object["spec"] = map[string]interface{}{
+		"replicas": int64(0),
+		"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app"}},
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "cache", "persistentVolumeClaim": map[string]interface{}{"claimName": "cache"}},
+			},
+		}},
+	}
+
+	sts := newResource("apps/v1", "StatefulSet", "db")
+	sts.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "db"}},
+		}},
+		"volumeClaimTemplates": []interface{}{
+			map[string]interface{}{"metadata": map[string]interface{}{"name": "data"}},
+		},
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), pod, deployment, sts,
+		newClaim("app-data", "1Gi", "standard"),
+		newClaim("cache", "1Gi", "standard"),
+		newClaim("data-db-0", "10Gi", "ssd"),
+		newClaim("data-db-old", "10Gi", "ssd"),
+		newClaim("orphaned", "5Gi", "standard"),
+	)
+
+	claims, err := UsedPersistentVolumeClaims(client, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := References{
+		"app-data":  {"pods/app"},
+		"cache":     {"deployments/scaled-down"},
+		"data-db-0": {"statefulsets/db"},
+	}
+	if diff := cmp.Diff(claims, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestPersistentVolumeClaims(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newClaim("orphaned", "5Gi", "standard"),
+		newClaim("pending", "", ""),
+	)
+
+	claims, err := PersistentVolumeClaims(client, "default", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Resource{
+		{Name: "orphaned", Kind: "persistentvolumeclaims", Details: "capacity=5Gi storageClass=standard"},
+		{Name: "pending", Kind: "persistentvolumeclaims"},
+	}
+	if diff := cmp.Diff(claims, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestReleasedPersistentVolumes(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newVolume("pvc-bound", "Bound"),
+		newVolume("pvc-released", "Released"),
+		newVolume("pvc-failed", "Failed"),
+		newVolume("pvc-available", "Available"),
+	)
+
+	volumes, err := ReleasedPersistentVolumes(client, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Resource{
+		{Name: "pvc-released", Kind: "persistentvolumes", Age: 48 * time.Hour, Status: Released, Details: "capacity=10Gi storageClass=standard"},
+		{Name: "pvc-failed", Kind: "persistentvolumes", Age: 48 * time.Hour, Status: Failed, Details: "capacity=10Gi storageClass=standard"},
+	}
+	if diff := cmp.Diff(volumes, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func newClaim(name, capacity, class string) *unstructured.Unstructured {
+	pvc := newResource("v1", "PersistentVolumeClaim", name)
+	if class != "" {
+		pvc.Object["spec"] = map[string]interface{}{"storageClassName": class}
+	}
+	if capacity != "" {
+		pvc.Object["status"] = map[string]interface{}{"phase": "Bound", "capacity": map[string]interface{}{"storage": capacity}}
+	}
+	return pvc
+}
+
+func newVolume(name, phase string) *unstructured.Unstructured {
+	pv := newResourceWithTime("v1", "PersistentVolume", name, time.Now().Add(-48*time.Hour))
+	pv.SetNamespace("")
+	pv.Object["spec"] = map[string]interface{}{
+		"capacity":         map[string]interface{}{"storage": "10Gi"},
+		"storageClassName": "standard",
+	}
+	pv.Object["status"] = map[string]interface{}{"phase": phase}
+	return pv
+}
@@ -0,0 +1,255 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Reasons a workload is broken.
+const (
+	// ScaledToZero workloads have been scaled to zero replicas
+	ScaledToZero Status = "ScaledToZero"
+
+	// Unavailable workloads want replicas, but have none available
+	Unavailable Status = "Unavailable"
+
+	// CrashLoopBackOff workloads have pods with a container repeatedly crashing
+	CrashLoopBackOff Status = "CrashLoopBackOff"
+
+	// ImagePullBackOff workloads have pods with a container image which can't be pulled
+	ImagePullBackOff Status = "ImagePullBackOff"
+)
+
+// BrokenWorkloads returns the workloads of type 'r' (Deployments or StatefulSets) in the namespace 'n' which are broken:
+// scaled to zero, or without any available replicas, for longer than 'd', or with pods crash looping or failing to pull
+// their image, which isn't gated by 'd' and is reported even while other replicas are available.
+// The status of each is every reason it's broken (i.e. "Unavailable,CrashLoopBackOff"), with the affected pods as details.
+func BrokenWorkloads(c dynamic.Interface, r schema.GroupVersionResource, n string, d time.Duration, a []string) ([]Resource, error) {
+	list, err := c.Resource(r).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting resource")
+	}
+
+	pods, err := c.Resource(PodSchema).Namespace(n).List(context.TODO(), meta_v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting pods")
+	}
+
+	now := time.Now()
+	nsProtection, err := namespaceProtection(c, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource []Resource
+	for _, workload := range list.Items {
+		if stringContainsArrayElement(workload.GetName(), a) {
+			continue
+		}
+
+		var reasons []string
+		var details []string
+
+		replicas, found, _ := unstructured.NestedInt64(workload.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+
+		if replicas == 0 {
+			scaled, err := scaledDownTime(workload)
+			if err != nil {
+				return nil, err
+			}
+			if now.Sub(scaled) > d {
+				reasons = append(reasons, string(ScaledToZero))
+				details = append(details, "since="+scaled.UTC().Format(time.RFC3339))
+			}
+		} else {
+			selector, err := workloadSelector(workload)
+			if err != nil {
+				return nil, err
+			}
+
+			if availableReplicas(workload) == 0 {
+				since, unavailable, err := unavailableTime(workload, pods.Items, selector)
+				if err != nil {
+					return nil, err
+				}
+				if unavailable && now.Sub(since) > d {
+					reasons = append(reasons, string(Unavailable))
+					details = append(details, "since="+since.UTC().Format(time.RFC3339))
+				}
+			}
+
+			if selector != nil {
+				failing := failingPods(pods.Items, selector)
+				for _, reason := range []Status{CrashLoopBackOff, ImagePullBackOff} {
+					if names := failing[reason]; len(names) > 0 {
+						reasons = append(reasons, string(reason))
+						details = append(details, fmt.Sprintf("%s=%s", reason, strings.Join(names, ",")))
+					}
+				}
+			}
+		}
+
+		if len(reasons) == 0 {
+			continue
+		}
+
+		age, err := objectAge(workload)
+		if err != nil {
+			return nil, err
+		}
+
+		resource = append(resource, Resource{
+			Name:      workload.GetName(),
+			Kind:      r.Resource,
+			Age:       age.Round(time.Minute),
+			Status:    Status(strings.Join(reasons, ",")),
+			Protected: objectProtection(&workload, nsProtection, now),
+			Details:   strings.Join(details, " "),
+		})
+	}
+
+	return resource, nil
+}
+
+// scaledDownTime estimates when the workload was scaled to zero, as the latest change to 'spec.replicas'
+// recorded in its managed fields, or the latest update to its conditions. Its creation is used when neither is recorded.
+func scaledDownTime(workload unstructured.Unstructured) (time.Time, error) {
+	var scaled time.Time
+	for _, entry := range workload.GetManagedFields() {
+		if entry.Time == nil || entry.FieldsV1 == nil {
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return time.Time{}, errors.Wrapf(err, "parsing managed fields of %s", workload.GetName())
+		}
+		if _, found, _ := unstructured.NestedFieldNoCopy(fields, "f:spec", "f:replicas"); found && entry.Time.After(scaled) {
+			scaled = entry.Time.Time
+		}
+	}
+	if !scaled.IsZero() {
+		return scaled, nil
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(workload.Object, "status", "conditions")
+	for _, c := range conditions {
+		updated, _, _ := unstructured.NestedString(c.(map[string]interface{}), "lastUpdateTime")
+		if t, err := time.Parse(time.RFC3339, updated); err == nil && t.After(scaled) {
+			scaled = t
+		}
+	}
+	if !scaled.IsZero() {
+		return scaled, nil
+	}
+
+	return objectCreation(workload)
+}
+
+// unavailableTime estimates when the workload without available replicas became unavailable, from the last transition
+// of its 'Available' condition, which is false when the condition reports it's still available.
+// Without the condition (i.e. StatefulSets), the latest creation of its pods is used, then the workload's creation.
+func unavailableTime(workload unstructured.Unstructured, pods []unstructured.Unstructured, selector labels.Selector) (time.Time, bool, error) {
+	conditions, _, _ := unstructured.NestedSlice(workload.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition := c.(map[string]interface{})
+		if t, _, _ := unstructured.NestedString(condition, "type"); t != "Available" {
+			continue
+		}
+
+		if status, _, _ := unstructured.NestedString(condition, "status"); status == "True" {
+			return time.Time{}, false, nil
+		}
+		transition, _, _ := unstructured.NestedString(condition, "lastTransitionTime")
+		if t, err := time.Parse(time.RFC3339, transition); err == nil {
+			return t, true, nil
+		}
+	}
+
+	var since time.Time
+	if selector != nil {
+		for _, pod := range pods {
+			if created := pod.GetCreationTimestamp(); selector.Matches(labels.Set(pod.GetLabels())) && created.After(since) {
+				since = created.Time
+			}
+		}
+	}
+	if !since.IsZero() {
+		return since, true, nil
+	}
+
+	created, err := objectCreation(workload)
+	return created, true, err
+}
+
+// availableReplicas returns the workload's available replicas, or its ready replicas for StatefulSets on
+// clusters older than v1.22 which don't report availability.
+func availableReplicas(workload unstructured.Unstructured) int64 {
+	if available, found, _ := unstructured.NestedInt64(workload.Object, "status", "availableReplicas"); found {
+		return available
+	}
+	ready, _, _ := unstructured.NestedInt64(workload.Object, "status", "readyReplicas")
+	return ready
+}
+
+// workloadSelector returns the label selector the workload uses to select its pods, or nil when it has none.
+func workloadSelector(workload unstructured.Unstructured) (labels.Selector, error) {
+	m, found, err := unstructured.NestedMap(workload.Object, "spec", "selector")
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var selector meta_v1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &selector); err != nil {
+		return nil, errors.Wrapf(err, "parsing selector of %s", workload.GetName())
+	}
+	return meta_v1.LabelSelectorAsSelector(&selector)
+}
+
+// failingPods returns the names of the pods matching 'selector', keyed by the reason their containers are waiting:
+// CrashLoopBackOff, or ImagePullBackOff (including 'ErrImagePull', before the back-off starts).
+func failingPods(pods []unstructured.Unstructured, selector labels.Selector) map[Status][]string {
+	failing := make(map[Status][]string)
+	for _, pod := range pods {
+		if !selector.Matches(labels.Set(pod.GetLabels())) {
+			continue
+		}
+
+		reasons := make(map[Status]bool)
+		for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+			statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+			for _, s := range statuses {
+				switch reason, _, _ := unstructured.NestedString(s.(map[string]interface{}), "state", "waiting", "reason"); reason {
+				case string(CrashLoopBackOff):
+					reasons[CrashLoopBackOff] = true
+				case string(ImagePullBackOff), "ErrImagePull":
+					reasons[ImagePullBackOff] = true
+				}
+			}
+		}
+
+		for reason := range reasons {
+			failing[reason] = append(failing[reason], pod.GetName())
+		}
+	}
+
+	for _, names := range failing {
+		sort.Strings(names)
+	}
+	return failing
+}
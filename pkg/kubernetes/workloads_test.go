@@ -0,0 +1,112 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestBrokenWorkloads(t *testing.T) {
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).Truncate(time.Second)
+
+	scaledDown := newWorkload("apps/v1", "Deployment", "scaled-down", 0, map[string]interface{}{"availableReplicas": int64(0)})
+	scaledDown.SetManagedFields([]meta_v1.ManagedFieldsEntry{
+		{Manager: "kubectl", Operation: meta_v1.ManagedFieldsOperationUpdate, Time: &meta_v1.Time{Time: lastWeek},
+			FieldsType: "FieldsV1", FieldsV1: &meta_v1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)}},
+		{Manager: "kube-controller-manager", Operation: meta_v1.ManagedFieldsOperationUpdate, Time: &meta_v1.Time{Time: time.Now()},
+			FieldsType: "FieldsV1", FieldsV1: &meta_v1.FieldsV1{Raw: []byte(`{"f:status":{"f:replicas":{}}}`)}},
+	})
+
+	// without managed fields, the latest condition update is used
+	recentlyScaled := newWorkload("apps/v1", "Deployment", "recently-scaled", 0, map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Available", "status": "True", "lastUpdateTime": time.Now().Format(time.RFC3339)}},
+	})
+
+	unavailable := func(since time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			"availableReplicas": int64(0),
+			"conditions":        []interface{}{map[string]interface{}{"type": "Available", "status": "False", "lastTransitionTime": since.Format(time.RFC3339)}},
+		}
+	}
+
+	// without an available condition, statefulsets are unavailable since their latest pod (or their creation)
+	db := newWorkload("apps/v1", "StatefulSet", "db", 1, map[string]interface{}{"readyReplicas": int64(0)})
+	db.SetCreationTimestamp(meta_v1.NewTime(lastWeek))
+	restarting := newWorkload("apps/v1", "StatefulSet", "restarting", 1, map[string]interface{}{"readyReplicas": int64(0)})
+	restarting.SetCreationTimestamp(meta_v1.NewTime(lastWeek))
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newWorkload("apps/v1", "Deployment", "healthy", 2, map[string]interface{}{"availableReplicas": int64(2)}),
+		scaledDown, recentlyScaled,
+		newWorkload("apps/v1", "Deployment", "crashing", 2, unavailable(lastWeek)),
+		newWorkload("apps/v1", "Deployment", "starting", 2, unavailable(time.Now())),
+		newWorkload("apps/v1", "Deployment", "pulling", 1, map[string]interface{}{"availableReplicas": int64(1)}),
+		newWorkload("apps/v1", "Deployment", "partial", 3, map[string]interface{}{"availableReplicas": int64(1)}),
+		db, restarting,
+		newWorkloadPod("crashing-a", "crashing", "CrashLoopBackOff"),
+		newWorkloadPod("crashing-b", "crashing", "CrashLoopBackOff"),
+		newWorkloadPod("starting-a", "starting", "CrashLoopBackOff"),
+		newWorkloadPod("pulling-a", "pulling", "ErrImagePull"),
+		newWorkloadPod("partial-a", "partial", ""),
+		newWorkloadPod("partial-b", "partial", "CrashLoopBackOff"),
+		newWorkloadPod("partial-c", "partial", "CrashLoopBackOff"),
+		newWorkloadPod("healthy-a", "healthy", ""),
+		newWorkloadPod("restarting-0", "restarting", ""),
+	)
+
+	deployments, err := BrokenWorkloads(client, DeploymentSchema, "default", 24*time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Resource{
+		{Name: "scaled-down", Kind: "deployments", Status: ScaledToZero, Details: "since=" + lastWeek.UTC().Format(time.RFC3339)},
+		{Name: "crashing", Kind: "deployments", Status: "Unavailable,CrashLoopBackOff", Details: "since=" + lastWeek.UTC().Format(time.RFC3339) + " CrashLoopBackOff=crashing-a,crashing-b"},
+		// failing pods are reported without waiting for '--age', even with other replicas available
+		{Name: "starting", Kind: "deployments", Status: CrashLoopBackOff, Details: "CrashLoopBackOff=starting-a"},
+		{Name: "pulling", Kind: "deployments", Status: ImagePullBackOff, Details: "ImagePullBackOff=pulling-a"},
+		{Name: "partial", Kind: "deployments", Status: CrashLoopBackOff, Details: "CrashLoopBackOff=partial-b,partial-c"},
+	}
+	if diff := cmp.Diff(deployments, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	statefulSets, err := BrokenWorkloads(client, StatefulSetSchema, "default", 24*time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []Resource{{Name: "db", Kind: "statefulsets", Age: 7 * 24 * time.Hour, Status: Unavailable, Details: "since=" + lastWeek.UTC().Format(time.RFC3339)}}
+	if diff := cmp.Diff(statefulSets, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func newWorkload(api, kind, name string, replicas int64, status map[string]interface{}) *unstructured.Unstructured {
+	workload := newResource(api, kind, name)
+	workload.Object["spec"] = map[string]interface{}{
+		"replicas": replicas,
+		"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+	}
+	workload.Object["status"] = status
+	return workload
+}
+
+func newWorkloadPod(name, app, waiting string) *unstructured.Unstructured {
+	pod := newResource("v1", "Pod", name)
+	pod.SetLabels(map[string]string{"app": app})
+	state := map[string]interface{}{"running": map[string]interface{}{}}
+	if waiting != "" {
+		state = map[string]interface{}{"waiting": map[string]interface{}{"reason": waiting}}
+	}
+	pod.Object["status"] = map[string]interface{}{
+		"phase":             "Running",
+		"containerStatuses": []interface{}{map[string]interface{}{"name": app, "state": state}},
+	}
+	return pod
+}
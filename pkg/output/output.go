@@ -0,0 +1,185 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Formats supported when writing results
+const (
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+	CSV   = "csv"
+)
+
+var csvHeader = []string{"kind", "namespace", "name", "age", "expires", "status", "action", "reason", "details"}
+
+// Validate returns an error for unsupported formats, so it can be checked before operating on the cluster.
+func Validate(f string) error {
+	switch f {
+	case Table, JSON, YAML, CSV:
+		return nil
+	default:
+		return errors.Errorf("unsupported output format %q (expected one of: table, json, yaml, csv)", f)
+	}
+}
+
+// Results writes the results of a single operation in the format 'f'.
+func Results(w io.Writer, f string, results []domain.Result) error {
+	if results == nil {
+		results = []domain.Result{}
+	}
+
+	switch f {
+	case Table:
+		t := newTabWriter(w)
+		writeTable(t, results)
+		return t.Flush()
+	case CSV:
+		c := csv.NewWriter(w)
+		_ = c.Write(csvHeader)
+		for _, r := range results {
+			_ = c.Write(csvRecord(r))
+		}
+		c.Flush()
+		return c.Error()
+	default:
+		return marshal(w, f, results)
+	}
+}
+
+// Rules writes the results of each policy rule in the format 'f'.
+// Tables are written per rule, followed by a combined summary of the actions taken.
+func Rules(w io.Writer, f string, rules []domain.RuleResult) error {
+	if rules == nil {
+		rules = []domain.RuleResult{}
+	}
+
+	switch f {
+	case Table:
+		t := newTabWriter(w)
+		for _, rule := range rules {
+			fmt.Fprintf(t, "RULE: %s (%s)\n", rule.Rule, rule.Operation)
+			if rule.Error != "" {
+				fmt.Fprintf(t, "Error: %s\n", rule.Error)
+			}
+			writeTable(t, rule.Results)
+			fmt.Fprintln(t)
+		}
+
+		fmt.Fprint(t, "SUMMARY\nRULE\tOPERATION\tNAMESPACES\tDELETED\tUN-CHANGED\tFAILED\tRESULT\n")
+		for _, rule := range rules {
+			counts := make(map[domain.Action]int)
+			for _, r := range rule.Results {
+				counts[r.Action]++
+			}
+
+			result := "OK"
+			if rule.Error != "" {
+				result = "FAILED"
+			}
+			fmt.Fprintf(t, "%s\t%s\t%d\t%d\t%d\t%d\t%s\n", rule.Rule, rule.Operation, len(rule.Namespaces),
+				counts[domain.Deleted], counts[domain.Unchanged], counts[domain.DeleteFailed], result)
+		}
+		return t.Flush()
+	case CSV:
+		c := csv.NewWriter(w)
+		_ = c.Write(append([]string{"rule"}, csvHeader...))
+		for _, rule := range rules {
+			if rule.Error != "" {
+				_ = c.Write([]string{rule.Rule, "", "", "", "", "", "", "", rule.Error, ""})
+			}
+			for _, r := range rule.Results {
+				_ = c.Write(append([]string{rule.Rule}, csvRecord(r)...))
+			}
+		}
+		c.Flush()
+		return c.Error()
+	default:
+		return marshal(w, f, rules)
+	}
+}
+
+func newTabWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 8, 8, 1, '\t', 0)
+}
+
+func writeTable(w io.Writer, results []domain.Result) {
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No resources found")
+		return
+	}
+
+	// details are only shown when present, as most operations don't have any
+	details := false
+	for _, r := range results {
+		details = details || r.Details != ""
+	}
+
+	fmt.Fprint(w, "KIND\tNAMESPACE\tNAME\tAGE\tEXPIRES\tSTATUS\tACTION\tREASON")
+	if details {
+		fmt.Fprint(w, "\tDETAILS")
+	}
+	fmt.Fprintln(w)
+
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", r.Kind, r.Namespace, r.Name, age(r.Age), expiry(r.Expires), r.Status, r.Action, r.Reason)
+		if details {
+			fmt.Fprintf(w, "\t%s", r.Details)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func csvRecord(r domain.Result) []string {
+	a := ""
+	if r.Age != 0 {
+		a = r.Age.String()
+	}
+	return []string{r.Kind, r.Namespace, r.Name, a, domain.FormatExpiry(r.Expires), r.Status, string(r.Action), r.Reason, r.Details}
+}
+
+func age(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.String()
+}
+
+func expiry(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return domain.FormatExpiry(t)
+}
+
+func marshal(w io.Writer, f string, v interface{}) error {
+	var data []byte
+	var err error
+
+	switch f {
+	case JSON:
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	case YAML:
+		data, err = yaml.Marshal(v)
+	default:
+		return Validate(f)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "marshalling results")
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,160 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ahstn/karetaker/pkg/domain"
+)
+
+var results = []domain.Result{
+	{Kind: "deployments", Namespace: "default", Name: "old-deploy", Age: 70 * time.Hour, Expires: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), Status: domain.Expired, Action: domain.Deleted},
+	{Kind: "configmaps", Namespace: "team-a", Name: "properties", Status: domain.InUse, Action: domain.Unchanged, Reason: domain.ReasonInUse},
+}
+
+func TestResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		results []domain.Result
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Writes a table",
+			format:  Table,
+			results: results,
+			want: "KIND\t\tNAMESPACE\tNAME\t\tAGE\tEXPIRES\t\t\tSTATUS\tACTION\t\tREASON\n" +
+				"deployments\tdefault\t\told-deploy\t70h0m0s\t2021-06-01T12:00:00Z\tEXPIRED\tDELETED\t\t\n" +
+				"configmaps\tteam-a\t\tproperties\t-\t-\t\t\tIN-USE\tUN-CHANGED\tin-use\n",
+		},
+		{
+			name:   "Writes details when present",
+			format: Table,
+			results: []domain.Result{
+				{Kind: "persistentvolumes", Name: "pvc-4f1c", Status: "Released", Action: domain.Unchanged, Reason: domain.ReasonDryRun, Details: "capacity=10Gi storageClass=standard"},
+			},
+			want: "KIND\t\t\tNAMESPACE\tNAME\t\tAGE\tEXPIRES\tSTATUS\t\tACTION\t\tREASON\tDETAILS\n" +
+				"persistentvolumes\t\t\tpvc-4f1c\t-\t-\tReleased\tUN-CHANGED\tdry-run\tcapacity=10Gi storageClass=standard\n",
+		},
+		{
+			name:   "Writes a message for empty tables",
+			format: Table,
+			want:   "No resources found\n",
+		},
+		{
+			name:    "Writes JSON",
+			format:  JSON,
+			results: results[1:],
+			want: `[
+  {
+    "kind": "configmaps",
+    "namespace": "team-a",
+    "name": "properties",
+    "status": "IN-USE",
+    "reason": "in-use",
+    "action": "UN-CHANGED"
+  }
+]
+`,
+		},
+		{
+			name:   "Writes an empty JSON list",
+			format: JSON,
+			want:   "[]\n",
+		},
+		{
+			name:    "Writes YAML",
+			format:  YAML,
+			results: results[:1],
+			want: `- action: DELETED
+  age: 70h0m0s
+  expires: "2021-06-01T12:00:00Z"
+  kind: deployments
+  name: old-deploy
+  namespace: default
+  status: EXPIRED
+`,
+		},
+		{
+			name:    "Writes CSV",
+			format:  CSV,
+			results: results,
+			want: "kind,namespace,name,age,expires,status,action,reason,details\n" +
+				"deployments,default,old-deploy,70h0m0s,2021-06-01T12:00:00Z,EXPIRED,DELETED,,\n" +
+				"configmaps,team-a,properties,,,IN-USE,UN-CHANGED,in-use,\n",
+		},
+		{
+			name:    "Returns an error for unsupported formats",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &bytes.Buffer{}
+			err := Results(o, tt.format, tt.results)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Results() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if o.String() != tt.want {
+				t.Errorf("Output error, \nexpected: %q \ngot: %q", tt.want, o.String())
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	rules := []domain.RuleResult{
+		{Rule: "old-deploys", Operation: domain.AgeOperation, Namespaces: []string{"default"}, Results: results[:1]},
+		{Rule: "configs", Operation: domain.UnusedOperation, Namespaces: []string{"default", "team-a"}, Error: "forbidden"},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "Writes a table per rule with a summary",
+			format: Table,
+			want: "RULE: old-deploys (age)\n" +
+				"KIND\t\tNAMESPACE\tNAME\t\tAGE\tEXPIRES\t\t\tSTATUS\tACTION\tREASON\n" +
+				"deployments\tdefault\t\told-deploy\t70h0m0s\t2021-06-01T12:00:00Z\tEXPIRED\tDELETED\t\n" +
+				"\n" +
+				"RULE: configs (unused)\n" +
+				"Error: forbidden\n" +
+				"No resources found\n" +
+				"\n" +
+				"SUMMARY\n" +
+				"RULE\t\tOPERATION\tNAMESPACES\tDELETED\tUN-CHANGED\tFAILED\tRESULT\n" +
+				"old-deploys\tage\t\t1\t\t1\t0\t\t0\tOK\n" +
+				"configs\t\tunused\t\t2\t\t0\t0\t\t0\tFAILED\n",
+		},
+		{
+			name:   "Writes CSV with the rule of each result",
+			format: CSV,
+			want: "rule,kind,namespace,name,age,expires,status,action,reason,details\n" +
+				"old-deploys,deployments,default,old-deploy,70h0m0s,2021-06-01T12:00:00Z,EXPIRED,DELETED,,\n" +
+				"configs,,,,,,,,forbidden,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &bytes.Buffer{}
+			if err := Rules(o, tt.format, rules); err != nil {
+				t.Errorf("Rules() error = %v", err)
+				return
+			}
+
+			if o.String() != tt.want {
+				t.Errorf("Output error, \nexpected: %q \ngot: %q", tt.want, o.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultActivityQuery is the query used to find a workload's activity, when none is configured.
+// It's the number of requests received by the workload's pods, as reported by Istio's sidecars. Workloads without any
+// requests have no series, so zero is returned instead, but only while Istio reports requests for any workload.
+const DefaultActivityQuery = `sum(increase(istio_requests_total{reporter="destination",destination_workload_namespace="{{ .Namespace }}",destination_workload="{{ .Name }}"}[{{ .Window }}]))` +
+	` or on() (0 * count(istio_requests_total{reporter="destination"}))`
+
+// ActivityQuery is a query template for the activity of a single workload over a window.
+type ActivityQuery struct {
+	template *template.Template
+}
+
+// ParseActivityQuery parses the query template 'q', or DefaultActivityQuery when empty.
+// Templates can use '{{ .Namespace }}', '{{ .Name }}' and '{{ .Window }}' (a Prometheus duration, i.e. "259200s").
+func ParseActivityQuery(q string) (ActivityQuery, error) {
+	if q == "" {
+		q = DefaultActivityQuery
+	}
+
+	t, err := template.New("activity").Option("missingkey=error").Parse(q)
+	if err != nil {
+		return ActivityQuery{}, errors.Wrap(err, "parsing activity query")
+	}
+	return ActivityQuery{template: t}, nil
+}
+
+// Render returns the query for the workload 'name' in the namespace 'n' over the window 'w'.
+func (q ActivityQuery) Render(n, name string, w time.Duration) (string, error) {
+	var b strings.Builder
+	err := q.template.Execute(&b, struct {
+		Namespace string
+		Name      string
+		Window    string
+	}{n, name, fmt.Sprintf("%ds", int64(w.Seconds()))})
+	if err != nil {
+		return "", errors.Wrap(err, "rendering activity query")
+	}
+	return b.String(), nil
+}
+
+// Idle returns if the workload 'name' in the namespace 'n' had no activity over the window 'w'.
+// A workload is idle when the query returns only samples of zero. A query without any samples is an error
+// rather than idle, as it's indistinguishable from a missing metric or a typo, which would select every workload.
+// NaN samples (i.e. from a ratio of 0/0) don't show a lack of activity either, so are an error unless another sample
+// shows activity.
+// Queries for metrics which are absent without activity can return an explicit zero with 'or on() vector(0)'.
+func Idle(c Client, q ActivityQuery, n, name string, w time.Duration) (bool, error) {
+	query, err := q.Render(n, name, w)
+	if err != nil {
+		return false, err
+	}
+
+	samples, err := c.Query(context.TODO(), query)
+	if err != nil {
+		return false, err
+	} else if len(samples) == 0 {
+		return false, errors.Errorf("activity query returned no samples: %s", query)
+	}
+
+	undefined := false
+	for _, s := range samples {
+		if math.IsNaN(s.Value) {
+			undefined = true
+		} else if s.Value != 0 {
+			return false, nil
+		}
+	}
+
+	if undefined {
+		return false, errors.Errorf("activity query returned NaN samples: %s", query)
+	}
+	return true, nil
+}
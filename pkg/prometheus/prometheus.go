@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Client evaluates queries against a Prometheus-compatible HTTP API (i.e. Prometheus, Thanos or VictoriaMetrics).
+type Client interface {
+	// Query evaluates the instant query 'q' at the current time, returning its samples
+	Query(ctx context.Context, q string) ([]Sample, error)
+}
+
+// Sample is a single series of an instant vector, with its labels and value.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// NewClient returns a Client for the Prometheus-compatible API at 'address' (i.e. "http://prometheus:9090").
+func NewClient(address string) Client {
+	return &httpClient{
+		address: strings.TrimSuffix(address, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type httpClient struct {
+	address string
+	client  *http.Client
+}
+
+// response is the envelope of every API response, see: https://prometheus.io/docs/prometheus/latest/querying/api/
+type response struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+func (c *httpClient) Query(ctx context.Context, q string) ([]Sample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+"/api/v1/query?"+url.Values{"query": {q}}.Encode(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating query")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying prometheus")
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, errors.Wrapf(err, "decoding prometheus response (%s)", resp.Status)
+	}
+	if r.Status != "success" {
+		return nil, errors.Errorf("prometheus query failed: %s: %s", r.ErrorType, r.Error)
+	}
+
+	return samples(r.Data.ResultType, r.Data.Result)
+}
+
+// samples parses the result of an instant query, where each value is a '[<timestamp>, "<value>"]' pair.
+// Scalar results are returned as a single sample without labels.
+func samples(resultType string, result json.RawMessage) ([]Sample, error) {
+	switch resultType {
+	case "vector":
+		var vector []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		}
+		if err := json.Unmarshal(result, &vector); err != nil {
+			return nil, errors.Wrap(err, "decoding vector")
+		}
+
+		s := make([]Sample, len(vector))
+		for i, v := range vector {
+			value, err := sampleValue(v.Value)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = Sample{Labels: v.Metric, Value: value}
+		}
+		return s, nil
+	case "scalar":
+		var scalar [2]interface{}
+		if err := json.Unmarshal(result, &scalar); err != nil {
+			return nil, errors.Wrap(err, "decoding scalar")
+		}
+
+		value, err := sampleValue(scalar)
+		if err != nil {
+			return nil, err
+		}
+		return []Sample{{Value: value}}, nil
+	default:
+		return nil, errors.Errorf("unsupported result type %q, the query must return an instant vector or scalar", resultType)
+	}
+}
+
+func sampleValue(v [2]interface{}) (float64, error) {
+	s, ok := v[1].(string)
+	if !ok {
+		return 0, errors.Errorf("unsupported sample value: %v", v[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,143 @@
+package prometheus
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []Sample
+		wantErr  bool
+	}{
+		{
+			name: "Vector samples are returned with their labels",
+			body: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"web-a"},"value":[1600000000.5,"12.5"]},{"metric":{},"value":[1600000000.5,"0"]}]}}`,
+			expected: []Sample{
+				{Labels: map[string]string{"pod": "web-a"}, Value: 12.5},
+				{Labels: map[string]string{}, Value: 0},
+			},
+		},
+		{
+			name:     "Scalar results are returned as a single sample",
+			body:     `{"status":"success","data":{"resultType":"scalar","result":[1600000000.5,"3"]}}`,
+			expected: []Sample{{Value: 3}},
+		},
+		{
+			name:     "Empty vectors return no samples",
+			body:     `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			expected: []Sample{},
+		},
+		{
+			name:    "Error is returned on range vectors",
+			body:    `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Error is returned on failed queries",
+			body:    `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/query" || r.URL.Query().Get("query") != "up" {
+					t.Errorf("unexpected request: %s", r.URL)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			actual, err := NewClient(server.URL+"/").Query(context.TODO(), "up")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if diff := cmp.Diff(actual, tt.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+// fakeClient returns the samples of each query, and records the queries made.
+type fakeClient struct {
+	samples map[string][]Sample
+	queries []string
+}
+
+func (c *fakeClient) Query(_ context.Context, q string) ([]Sample, error) {
+	c.queries = append(c.queries, q)
+	return c.samples[q], nil
+}
+
+func TestIdle(t *testing.T) {
+	q, err := ParseActivityQuery(`requests{namespace="{{ .Namespace }}",app="{{ .Name }}"}[{{ .Window }}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &fakeClient{samples: map[string][]Sample{
+		`requests{namespace="default",app="active"}[259200s]`: {{Value: 0}, {Value: 4}},
+		`requests{namespace="default",app="quiet"}[259200s]`:  {{Value: 0}},
+		`requests{namespace="default",app="ratio"}[259200s]`:  {{Value: math.NaN()}, {Value: 0.5}},
+		`requests{namespace="default",app="nan"}[259200s]`:    {{Value: 0}, {Value: math.NaN()}},
+	}}
+
+	for name, expected := range map[string]bool{"active": false, "quiet": true, "ratio": false} {
+		idle, err := Idle(c, q, "default", name, 72*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idle != expected {
+			t.Errorf("expected %s idle to be %v", name, expected)
+		}
+	}
+
+	// without any samples, the metric may not exist at all, so the workload isn't idle
+	if idle, err := Idle(c, q, "default", "absent", 72*time.Hour); err == nil || idle {
+		t.Errorf("expected an error without samples, got idle = %v", idle)
+	}
+
+	// NaN is usually a ratio of 0/0 (i.e. an error rate), rather than a lack of activity
+	if idle, err := Idle(c, q, "default", "nan", 72*time.Hour); err == nil || idle {
+		t.Errorf("expected an error with NaN samples, got idle = %v", idle)
+	}
+}
+
+func TestParseActivityQuery(t *testing.T) {
+	q, err := ParseActivityQuery("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := q.Render("default", "web", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `sum(increase(istio_requests_total{reporter="destination",destination_workload_namespace="default",destination_workload="web"}[3600s]))` +
+		` or on() (0 * count(istio_requests_total{reporter="destination"}))`
+	if actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	if _, err := ParseActivityQuery("{{ .Name "); err == nil {
+		t.Error("expected an error on an invalid template")
+	}
+
+	q, _ = ParseActivityQuery("{{ .Deployment }}")
+	if _, err := q.Render("default", "web", time.Hour); err == nil {
+		t.Error("expected an error on an unknown template field")
+	}
+}